@@ -4,9 +4,11 @@ import (
 	"log"
 	"os"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 var DB *sqlx.DB
@@ -22,8 +24,13 @@ func ConfDb() {
 		log.Fatal("POSTGRESQL_CONNECTION_URI is not set in environment variables")
 	}
 
-	db, err := sqlx.Connect("postgres", connStr)
+	// otelsql tạo span cho từng query
+	sqlDb, err := otelsql.Open("postgres", connStr, otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
 	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	db := sqlx.NewDb(sqlDb, "postgres")
+	if err := db.Ping(); err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	DB = db
@@ -11,6 +11,36 @@ const (
 	ErrorCode_USER_ALREADY_EXISTS        ErrorCode = 1002
 	ErrorCode_USER_NOT_FOUND             ErrorCode = 1003
 	ErrorCode_APP_NOT_FOUND              ErrorCode = 2001
+	ErrorCode_BUILD_NOT_FOUND            ErrorCode = 2002
+	ErrorCode_APK_NOT_AVAILABLE          ErrorCode = 2003
+	ErrorCode_INVALID_APK                ErrorCode = 2004
+	ErrorCode_APK_PACKAGE_CONFLICT       ErrorCode = 2005
+	ErrorCode_INVALID_THEME_COLOR        ErrorCode = 2006
+	ErrorCode_INVALID_APP_ICON           ErrorCode = 2007
+	ErrorCode_INVALID_APP_URI            ErrorCode = 2008
+	ErrorCode_APP_URI_UNREACHABLE        ErrorCode = 2009
+	ErrorCode_CATEGORY_NOT_FOUND         ErrorCode = 2010
+	ErrorCode_INVALID_CATEGORY           ErrorCode = 2011
+	ErrorCode_CATEGORY_IN_USE            ErrorCode = 2012
+	ErrorCode_TAG_NOT_FOUND              ErrorCode = 2013
+	ErrorCode_INVALID_TAG                ErrorCode = 2014
+	ErrorCode_SLUG_ALREADY_EXISTS        ErrorCode = 2015
+	ErrorCode_COLLECTION_NOT_FOUND       ErrorCode = 2016
+	ErrorCode_INVALID_COLLECTION         ErrorCode = 2017
+	ErrorCode_RATING_ALREADY_EXISTS      ErrorCode = 3001
+	ErrorCode_INVALID_RATING_STARS       ErrorCode = 3002
+	ErrorCode_RATING_NOT_FOUND           ErrorCode = 3003
+	ErrorCode_INVALID_REPORT_REASON      ErrorCode = 3004
+	ErrorCode_RATING_ALREADY_REPORTED    ErrorCode = 3005
+	ErrorCode_RATING_IS_OWN              ErrorCode = 3006
+	ErrorCode_INVALID_RATING_STATUS      ErrorCode = 3007
+	ErrorCode_RATING_COMMENT_REJECTED    ErrorCode = 3008
+	ErrorCode_RATING_REPLY_FORBIDDEN     ErrorCode = 3009
+	ErrorCode_INVALID_RATING_REPLY       ErrorCode = 3010
+	ErrorCode_WEBHOOK_NOT_FOUND          ErrorCode = 4001
+	ErrorCode_INVALID_WEBHOOK_URL        ErrorCode = 4002
+	ErrorCode_INVALID_WEBHOOK_EVENT      ErrorCode = 4003
+	ErrorCode_INVALID_WEBHOOK_SIGNATURE  ErrorCode = 4004
 	ErrorCode_USER_NOT_ACTIVE            ErrorCode = 1004
 	ErrorCode_AUTH_FAILED                ErrorCode = 1005
 	ErrorCode_INVALID_TOKEN              ErrorCode = 1006
@@ -19,6 +49,16 @@ const (
 	ErrorCode_INVALID_REQUEST            ErrorCode = 1009
 	ErrorCode_MISSING_AUTH_HEADER        ErrorCode = 1010
 	ErrorCode_INVALID_AUTH_HEADER_FORMAT ErrorCode = 1011
+	ErrorCode_API_KEY_NOT_FOUND          ErrorCode = 1012
+	ErrorCode_INVALID_API_KEY            ErrorCode = 1013
+	ErrorCode_INVALID_API_KEY_SCOPE      ErrorCode = 1014
+	ErrorCode_API_KEY_NOT_ALLOWED        ErrorCode = 1015
+	ErrorCode_USER_NOT_RESTORABLE        ErrorCode = 1016
+	ErrorCode_EXPORT_NOT_FOUND           ErrorCode = 1017
+	ErrorCode_EXPORT_LINK_INVALID        ErrorCode = 1018
+	ErrorCode_EXPORT_ALREADY_PENDING     ErrorCode = 1019
+	ErrorCode_NOTIFICATION_NOT_FOUND     ErrorCode = 1020
+	ErrorCode_INVALID_NOTIFICATION_TYPE  ErrorCode = 1021
 
 	// Lỗi hệ thống (số âm)
 	ErrorCode_FAILED_TO_HASH_PASSWORD  ErrorCode = -1001
@@ -26,6 +66,7 @@ const (
 	ErrorCode_FAILED_TO_CREATE_USER    ErrorCode = -1003
 	ErrorCode_FAILED_TO_GENERATE_TOKEN ErrorCode = -1004
 	ErrorCode_FAILED_TO_INSERT_USER    ErrorCode = -1005
+	ErrorCode_FAILED_TO_GENERATE_KEY   ErrorCode = -1006
 )
 
 var errorMessages = map[ErrorCode]string{
@@ -35,6 +76,36 @@ var errorMessages = map[ErrorCode]string{
 	ErrorCode_USER_ALREADY_EXISTS:        "USER_ALREADY_EXISTS",
 	ErrorCode_USER_NOT_FOUND:             "USER_NOT_FOUND",
 	ErrorCode_APP_NOT_FOUND:              "APP_NOT_FOUND",
+	ErrorCode_BUILD_NOT_FOUND:            "BUILD_NOT_FOUND",
+	ErrorCode_APK_NOT_AVAILABLE:          "APK_NOT_AVAILABLE",
+	ErrorCode_INVALID_APK:                "INVALID_APK",
+	ErrorCode_APK_PACKAGE_CONFLICT:       "APK_PACKAGE_CONFLICT",
+	ErrorCode_INVALID_THEME_COLOR:        "INVALID_THEME_COLOR",
+	ErrorCode_INVALID_APP_ICON:           "INVALID_APP_ICON",
+	ErrorCode_INVALID_APP_URI:            "INVALID_APP_URI",
+	ErrorCode_APP_URI_UNREACHABLE:        "APP_URI_UNREACHABLE",
+	ErrorCode_CATEGORY_NOT_FOUND:         "CATEGORY_NOT_FOUND",
+	ErrorCode_INVALID_CATEGORY:           "INVALID_CATEGORY",
+	ErrorCode_CATEGORY_IN_USE:            "CATEGORY_IN_USE",
+	ErrorCode_TAG_NOT_FOUND:              "TAG_NOT_FOUND",
+	ErrorCode_INVALID_TAG:                "INVALID_TAG",
+	ErrorCode_SLUG_ALREADY_EXISTS:        "SLUG_ALREADY_EXISTS",
+	ErrorCode_COLLECTION_NOT_FOUND:       "COLLECTION_NOT_FOUND",
+	ErrorCode_INVALID_COLLECTION:         "INVALID_COLLECTION",
+	ErrorCode_RATING_ALREADY_EXISTS:      "RATING_ALREADY_EXISTS",
+	ErrorCode_INVALID_RATING_STARS:       "INVALID_RATING_STARS",
+	ErrorCode_RATING_NOT_FOUND:           "RATING_NOT_FOUND",
+	ErrorCode_INVALID_REPORT_REASON:      "INVALID_REPORT_REASON",
+	ErrorCode_RATING_ALREADY_REPORTED:    "RATING_ALREADY_REPORTED",
+	ErrorCode_RATING_IS_OWN:              "RATING_IS_OWN",
+	ErrorCode_INVALID_RATING_STATUS:      "INVALID_RATING_STATUS",
+	ErrorCode_RATING_COMMENT_REJECTED:    "RATING_COMMENT_REJECTED",
+	ErrorCode_RATING_REPLY_FORBIDDEN:     "RATING_REPLY_FORBIDDEN",
+	ErrorCode_INVALID_RATING_REPLY:       "INVALID_RATING_REPLY",
+	ErrorCode_WEBHOOK_NOT_FOUND:          "WEBHOOK_NOT_FOUND",
+	ErrorCode_INVALID_WEBHOOK_URL:        "INVALID_WEBHOOK_URL",
+	ErrorCode_INVALID_WEBHOOK_EVENT:      "INVALID_WEBHOOK_EVENT",
+	ErrorCode_INVALID_WEBHOOK_SIGNATURE:  "INVALID_WEBHOOK_SIGNATURE",
 	ErrorCode_USER_NOT_ACTIVE:            "USER_NOT_ACTIVE",
 	ErrorCode_AUTH_FAILED:                "AUTH_FAILED",
 	ErrorCode_INVALID_TOKEN:              "INVALID_TOKEN",
@@ -43,6 +114,16 @@ var errorMessages = map[ErrorCode]string{
 	ErrorCode_INVALID_REQUEST:            "INVALID_REQUEST",
 	ErrorCode_MISSING_AUTH_HEADER:        "MISSING_AUTH_HEADER",
 	ErrorCode_INVALID_AUTH_HEADER_FORMAT: "INVALID_AUTH_HEADER_FORMAT",
+	ErrorCode_API_KEY_NOT_FOUND:          "API_KEY_NOT_FOUND",
+	ErrorCode_INVALID_API_KEY:            "INVALID_API_KEY",
+	ErrorCode_INVALID_API_KEY_SCOPE:      "INVALID_API_KEY_SCOPE",
+	ErrorCode_API_KEY_NOT_ALLOWED:        "API_KEY_NOT_ALLOWED",
+	ErrorCode_USER_NOT_RESTORABLE:        "USER_NOT_RESTORABLE",
+	ErrorCode_EXPORT_NOT_FOUND:           "EXPORT_NOT_FOUND",
+	ErrorCode_EXPORT_LINK_INVALID:        "EXPORT_LINK_INVALID",
+	ErrorCode_EXPORT_ALREADY_PENDING:     "EXPORT_ALREADY_PENDING",
+	ErrorCode_NOTIFICATION_NOT_FOUND:     "NOTIFICATION_NOT_FOUND",
+	ErrorCode_INVALID_NOTIFICATION_TYPE:  "INVALID_NOTIFICATION_TYPE",
 
 	// System errors
 	ErrorCode_FAILED_TO_HASH_PASSWORD:  "FAILED_TO_HASH_PASSWORD",
@@ -50,6 +131,7 @@ var errorMessages = map[ErrorCode]string{
 	ErrorCode_FAILED_TO_CREATE_USER:    "FAILED_TO_CREATE_USER",
 	ErrorCode_FAILED_TO_GENERATE_TOKEN: "FAILED_TO_GENERATE_TOKEN",
 	ErrorCode_FAILED_TO_INSERT_USER:    "FAILED_TO_INSERT_USER",
+	ErrorCode_FAILED_TO_GENERATE_KEY:   "FAILED_TO_GENERATE_KEY",
 }
 
 func GetErrString(code ErrorCode) string {
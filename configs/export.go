@@ -0,0 +1,19 @@
+package configs
+
+import (
+	"log"
+	"os"
+)
+
+// ExportSigningSecret là khoá HMAC ký link tải data export
+var ExportSigningSecret []byte
+
+// ConfExport đọc EXPORT_SIGNING_SECRET; thiếu secret thì dừng ngay vì link đã phát
+// phải còn hiệu lực sau restart và giống nhau giữa các instance
+func ConfExport() {
+	secret := os.Getenv("EXPORT_SIGNING_SECRET")
+	if secret == "" {
+		log.Fatal("EXPORT_SIGNING_SECRET is not set in environment variables")
+	}
+	ExportSigningSecret = []byte(secret)
+}
@@ -1,41 +1,208 @@
 package configs
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"math/big"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/joho/godotenv"
 )
 
-var JwtSecret string
+// JwtKey là một khoá trong key ring. Private == nil nghĩa là khoá đã nghỉ hưu,
+// chỉ còn dùng để verify token cũ trong thời gian rotate.
+type JwtKey struct {
+	Kid     string
+	Private crypto.Signer
+	Public  crypto.PublicKey
+	Method  jwt.SigningMethod
+}
+
+var (
+	JwtIssuer   string
+	JwtAudience string
+	JwtTTL      = time.Hour * 72
+
+	jwtKeys      = map[string]*JwtKey{}
+	jwtActiveKid string
+)
+
+// IsDevelopment cho biết server chạy ở môi trường phát triển (APP_ENV=development)
+func IsDevelopment() bool {
+	return strings.EqualFold(os.Getenv("APP_ENV"), "development")
+}
 
+// ConfJwt nạp key ring từ JWT_KEYS_DIR: mỗi file <kid>.pem là một private key
+// (RSA hoặc Ed25519) hoặc public key của khoá đã nghỉ hưu. JWT_ACTIVE_KID chọn
+// khoá dùng để ký; mặc định là private key có kid lớn nhất theo thứ tự từ điển.
+// Ngoài môi trường development, thiếu private key thì dừng ngay: khoá tạm làm mọi
+// token mất hiệu lực khi restart và khác nhau giữa các replica.
 func ConfJwt() {
 	godotenv.Load()
-	JwtSecret = os.Getenv("JWT_SECRET")
-	if JwtSecret == "" {
-		JwtSecret = "default_secret" // fallback nếu không có biến môi trường
+	JwtIssuer = os.Getenv("JWT_ISSUER")
+	if JwtIssuer == "" {
+		JwtIssuer = "waheim"
+	}
+	JwtAudience = os.Getenv("JWT_AUDIENCE")
+	if JwtAudience == "" {
+		JwtAudience = "waheim.api"
+	}
+
+	jwtKeys = map[string]*JwtKey{}
+	jwtActiveKid = ""
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir != "" {
+		if err := loadJwtKeys(dir); err != nil {
+			Fatal("failed to load JWT keys", "dir", dir, "error", err)
+		}
+	}
+
+	jwtActiveKid = os.Getenv("JWT_ACTIVE_KID")
+	if jwtActiveKid == "" {
+		kids := make([]string, 0, len(jwtKeys))
+		for kid, key := range jwtKeys {
+			if key.Private != nil {
+				kids = append(kids, kid)
+			}
+		}
+		sort.Strings(kids)
+		if len(kids) > 0 {
+			jwtActiveKid = kids[len(kids)-1]
+		}
+	}
+
+	if jwtActiveKid == "" {
+		if !IsDevelopment() {
+			Fatal("no JWT signing key: set JWT_KEYS_DIR to a directory with a private key (or APP_ENV=development)", "dir", dir)
+		}
+		// Chỉ khi phát triển: sinh khoá tạm, token sẽ mất hiệu lực khi restart
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			Fatal("failed to generate JWT key", "error", err)
+		}
+		jwtActiveKid = "ephemeral-" + time.Now().UTC().Format("20060102150405")
+		jwtKeys[jwtActiveKid] = &JwtKey{Kid: jwtActiveKid, Private: priv, Public: priv.Public(), Method: jwt.SigningMethodEdDSA}
+		Logger("configs").Warn("using an ephemeral Ed25519 JWT signing key", "kid", jwtActiveKid)
+	}
+
+	key, ok := jwtKeys[jwtActiveKid]
+	if !ok || key.Private == nil {
+		Fatal("JWT_ACTIVE_KID does not match any private key", "kid", jwtActiveKid)
 	}
 }
 
+func loadJwtKeys(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := parseJwtKey(kid, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		jwtKeys[kid] = key
+	}
+	return nil
+}
+
+func parseJwtKey(kid string, data []byte) (*JwtKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &JwtKey{Kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Private, key.Public, key.Method = k, &k.PublicKey, jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		key.Private, key.Public, key.Method = k, k.Public(), jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		key.Public, key.Method = k, jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Public, key.Method = k, jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
 func GenerateJwt(userId string, role string) (string, error) {
+	key, ok := jwtKeys[jwtActiveKid]
+	if !ok || key.Private == nil {
+		return "", errors.New("no active signing key")
+	}
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := jwt.MapClaims{
-		"user_id": userId,
-		"role":    role,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
+		"iss":  JwtIssuer,
+		"aud":  JwtAudience,
+		"sub":  userId,
+		"iat":  now.Unix(),
+		"exp":  now.Add(JwtTTL).Unix(),
+		"jti":  hex.EncodeToString(jti),
+		"role": role,
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(JwtSecret))
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.Private)
 }
 
 func ValidateJwt(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := jwtKeys[kid]
+		if !ok {
+			return nil, errors.New("unknown kid")
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(JwtSecret), nil
-	})
+		return key.Public, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(JwtIssuer),
+		jwt.WithAudience(JwtAudience),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -44,3 +211,38 @@ func ValidateJwt(tokenString string) (jwt.MapClaims, error) {
 	}
 	return nil, errors.New("invalid token")
 }
+
+// Jwks trả về toàn bộ public key (kể cả khoá đã nghỉ hưu) theo định dạng RFC 7517
+func Jwks() map[string]interface{} {
+	kids := make([]string, 0, len(jwtKeys))
+	for kid := range jwtKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := []map[string]string{}
+	for _, kid := range kids {
+		key := jwtKeys[kid]
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": key.Method.Alg(),
+				"kid": kid,
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP",
+				"use": "sig",
+				"alg": key.Method.Alg(),
+				"kid": kid,
+				"crv": "Ed25519",
+				"x":   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return map[string]interface{}{"keys": keys}
+}
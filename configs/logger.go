@@ -0,0 +1,179 @@
+package configs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIdKeyType struct{}
+
+var requestIdKey = requestIdKeyType{}
+
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey, id)
+}
+
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey).(string)
+	return id
+}
+
+var (
+	baseHandler atomic.Pointer[slog.Handler]
+	logLevels   atomic.Pointer[map[string]slog.Level]
+	defaultLvl  atomic.Int64
+)
+
+func init() {
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{ReplaceAttr: redactAttr})
+	baseHandler.Store(&h)
+	logLevels.Store(&map[string]slog.Level{})
+}
+
+// ConfLogger cấu hình slog từ env:
+//   - LOG_FORMAT: "text" (mặc định) hoặc "json"
+//   - LOG_LEVEL: mức log mặc định (debug, info, warn, error)
+//   - LOG_LEVELS: mức log theo package, ví dụ "repositories=debug,handlers=warn"
+func ConfLogger() {
+	godotenv.Load()
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: redactAttr}
+	var h slog.Handler
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "json") {
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	} else {
+		h = slog.NewTextHandler(os.Stderr, opts)
+	}
+	baseHandler.Store(&h)
+
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		lvl = slog.LevelInfo
+	}
+	defaultLvl.Store(int64(lvl))
+
+	levels := map[string]slog.Level{}
+	for _, pair := range strings.Split(os.Getenv("LOG_LEVELS"), ",") {
+		pkg, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(value)); err == nil {
+			levels[pkg] = l
+		}
+	}
+	logLevels.Store(&levels)
+	slog.SetDefault(Logger("main"))
+}
+
+// Fatal ghi lỗi qua slog (định dạng và che PII như mọi log khác) rồi thoát, dùng khi cấu hình sai lúc khởi động
+func Fatal(msg string, args ...any) {
+	Logger("configs").Error(msg, args...)
+	os.Exit(1)
+}
+
+// Logger trả về logger của một package. Có thể gọi trước ConfLogger;
+// cấu hình được đọc lại mỗi lần ghi log.
+func Logger(pkg string) *slog.Logger {
+	return slog.New(&pkgHandler{pkg: pkg}).With("pkg", pkg)
+}
+
+// pkgHandler lọc theo mức log của package, gắn request_id từ context
+// rồi chuyển cho handler gốc hiện tại
+type pkgHandler struct {
+	pkg   string
+	attrs []slog.Attr
+	group string
+}
+
+func (h *pkgHandler) level() slog.Level {
+	if l, ok := (*logLevels.Load())[h.pkg]; ok {
+		return l
+	}
+	return slog.Level(defaultLvl.Load())
+}
+
+func (h *pkgHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= h.level()
+}
+
+func (h *pkgHandler) Handle(ctx context.Context, r slog.Record) error {
+	inner := *baseHandler.Load()
+	if len(h.attrs) > 0 {
+		inner = inner.WithAttrs(h.attrs)
+	}
+	if h.group != "" {
+		inner = inner.WithGroup(h.group)
+	}
+	if id := RequestId(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return inner.Handle(ctx, r)
+}
+
+func (h *pkgHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &pkgHandler{pkg: h.pkg, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...), group: h.group}
+}
+
+func (h *pkgHandler) WithGroup(name string) slog.Handler {
+	return &pkgHandler{pkg: h.pkg, attrs: h.attrs, group: name}
+}
+
+var (
+	// Attr có tên là (hoặc kết thúc bằng "_" + ) một trong các từ này bị che toàn bộ giá trị,
+	// ví dụ "email", "user_email", "access_token"; "api_key_id" thì không
+	sensitiveKeys = []string{
+		"password", "token", "authorization", "cookie", "secret", "api_key",
+		"email", "phone", "address", "waheim_id",
+	}
+	// Key chứa văn bản tự do, có thể lẫn PII nên được quét theo nội dung
+	freeTextKeys = map[string]bool{slog.MessageKey: true, "error": true, "err": true, "reason": true}
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`(?:\+|\b0)\d[\d\s\-]{7,13}\d`)
+	tokenPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+|whk_[A-Za-z0-9_\-]+`)
+)
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if key == k || strings.HasSuffix(key, "_"+k) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr che PII theo tên key. Chỉ message và lỗi (văn bản tự do) mới được quét theo nội dung
+// (email, số điện thoại, token); các attr khác như id, hash giữ nguyên để còn tra cứu được
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, "[REDACTED]")
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if freeTextKeys[strings.ToLower(a.Key)] {
+			return slog.String(a.Key, Redact(a.Value.String()))
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
+
+func Redact(s string) string {
+	s = tokenPattern.ReplaceAllString(s, "[TOKEN]")
+	s = emailPattern.ReplaceAllString(s, "[EMAIL]")
+	return phonePattern.ReplaceAllString(s, "[PHONE]")
+}
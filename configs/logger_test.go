@@ -0,0 +1,41 @@
+package configs
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactAttr(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactAttr}))
+
+	buildId := "3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b"
+	hash := "0981234567abcdef0981234567abcdef"
+	log.Info("sign in failed for an@example.com",
+		"build_id", buildId,
+		"sha256", hash,
+		"api_key_id", "0987654321",
+		"email", "an@example.com",
+		"user_phone", "0912345678",
+		"authorization", "Bearer abc",
+		"error", errors.New("duplicate phone 0912 345 678"),
+	)
+	out := buf.String()
+
+	for _, keep := range []string{"build_id=" + buildId, "sha256=" + hash, "api_key_id=0987654321"} {
+		if !strings.Contains(out, keep) {
+			t.Errorf("%q altered: %s", keep, out)
+		}
+	}
+	for _, leak := range []string{"an@example.com", "0912345678", "0912 345 678", "Bearer abc"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("%q not redacted: %s", leak, out)
+		}
+	}
+	if !strings.Contains(out, "[EMAIL]") || !strings.Contains(out, "[PHONE]") {
+		t.Errorf("free text not scanned: %s", out)
+	}
+}
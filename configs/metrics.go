@@ -0,0 +1,59 @@
+package configs
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+var (
+	Metrics = prometheus.NewRegistry()
+
+	HttpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "waheim",
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	SignInTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "waheim",
+		Name:      "sign_in_total",
+		Help:      "Sign-in attempts by result.",
+	}, []string{"result"})
+
+	BuildDispatchTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "waheim",
+		Name:      "build_dispatch_total",
+		Help:      "APK build dispatches by outcome.",
+	}, []string{"outcome"})
+
+	AppHealthChecksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "waheim",
+		Name:      "app_health_checks_total",
+		Help:      "App URI probes by result.",
+	}, []string{"result"})
+
+	UploadSizeBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "waheim",
+		Name:      "upload_size_bytes",
+		Help:      "Size of stored uploads by target.",
+		Buckets:   prometheus.ExponentialBuckets(1024, 4, 10),
+	}, []string{"target"})
+)
+
+func init() {
+	Metrics.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HttpRequestDuration,
+		SignInTotal,
+		BuildDispatchTotal,
+		AppHealthChecksTotal,
+		UploadSizeBytes,
+	)
+}
+
+// ConfMetrics đăng ký các metric phụ thuộc vào kết nối DB, gọi sau ConfDb
+func ConfMetrics() {
+	Metrics.MustRegister(collectors.NewDBStatsCollector(DB.DB, "waheim"))
+}
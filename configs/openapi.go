@@ -0,0 +1,225 @@
+package configs
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RouteDoc mô tả một route để sinh OpenAPI. Path dùng cú pháp của Gin (/app/:id).
+type RouteDoc struct {
+	Method   string
+	Path     string
+	Summary  string
+	Tag      string
+	Auth     bool
+	Query    []string
+	Request  interface{}
+	Response interface{}
+	// Status mặc định là 200
+	Status      int
+	ContentType string
+	// RequestContentType mặc định là application/json
+	RequestContentType string
+}
+
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// OpenAPIPath đổi path kiểu Gin sang kiểu OpenAPI (/app/{id})
+func OpenAPIPath(path string) string {
+	return ginParam.ReplaceAllString(path, "{$1}")
+}
+
+// BuildOpenAPI sinh tài liệu OpenAPI 3.1 từ danh sách route và kiểu request/response
+func BuildOpenAPI(title, version string, routes []RouteDoc) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]map[string]interface{}{}
+
+	for _, route := range routes {
+		path := OpenAPIPath(route.Path)
+		op := map[string]interface{}{
+			"summary":     route.Summary,
+			"operationId": operationId(route.Method, path),
+		}
+		if route.Tag != "" {
+			op["tags"] = []string{route.Tag}
+		}
+		params := []map[string]interface{}{}
+		for _, m := range ginParam.FindAllStringSubmatch(route.Path, -1) {
+			params = append(params, map[string]interface{}{
+				"name": m[1], "in": "path", "required": true,
+				"schema": map[string]string{"type": "string"},
+			})
+		}
+		for _, q := range route.Query {
+			params = append(params, map[string]interface{}{
+				"name": q, "in": "query",
+				"schema": map[string]string{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if route.Auth {
+			op["security"] = []map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}}
+		}
+		if route.Request != nil {
+			requestType := route.RequestContentType
+			if requestType == "" {
+				requestType = "application/json"
+			}
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					requestType: map[string]interface{}{"schema": schemaOf(reflect.TypeOf(route.Request), schemas)},
+				},
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		resp := map[string]interface{}{"description": http.StatusText(status)}
+		if route.Response != nil {
+			contentType := route.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			resp["content"] = map[string]interface{}{
+				contentType: map[string]interface{}{"schema": schemaOf(reflect.TypeOf(route.Response), schemas)},
+			}
+		}
+		op["responses"] = map[string]interface{}{
+			strconv.Itoa(status): resp,
+			"default":            map[string]interface{}{"description": "Error in the form CODE:MESSAGE"},
+		}
+
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info":    map[string]string{"title": title, "version": version},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKeyAuth": map[string]string{"type": "apiKey", "in": "header", "name": "X-Api-Key"},
+			},
+		},
+	}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaOf sinh JSON Schema theo đúng cách encoding/json sẽ encode kiểu t.
+// Struct có tên được đưa vào components/schemas và tham chiếu bằng $ref.
+func schemaOf(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawJSONType:
+		return map[string]interface{}{}
+	case t.Implements(textMarshaler) || reflect.PointerTo(t).Implements(textMarshaler):
+		return map[string]interface{}{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return structSchema(t, schemas)
+		}
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = map[string]interface{}{} // chặn đệ quy
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{}
+	required := []string{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" {
+				ft := f.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft)
+					continue
+				}
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = schemaOf(f.Type, schemas)
+			if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+				required = append(required, name)
+			}
+		}
+	}
+	walk(t)
+	sort.Strings(required)
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func operationId(method, path string) string {
+	parts := []string{strings.ToLower(method)}
+	for _, p := range strings.Split(path, "/") {
+		p = strings.Trim(p, "{}")
+		p = strings.NewReplacer("-", "", ".", "").Replace(p)
+		if p != "" {
+			parts = append(parts, strings.ToUpper(p[:1])+p[1:])
+		}
+	}
+	return strings.Join(parts, "")
+}
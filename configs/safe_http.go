@@ -0,0 +1,82 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// SafeClient dùng cho URL do user nhập (crawl web app, webhook của publisher):
+// chặn IP nội bộ, giới hạn thời gian và số lần redirect
+var SafeClient = NewSafeClient(10*time.Second, false)
+
+var ErrPrivateAddress = errors.New("destination resolves to a private address")
+
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublicAddr trả về false cho loopback, mạng nội bộ, link-local, multicast...
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!cgnatPrefix.Contains(addr) &&
+		!(addr.Is4() && addr.As4()[0] == 0)
+}
+
+// NewSafeClient tạo client kiểm tra IP ngay lúc dial (sau khi resolve DNS) nên
+// không bị vượt qua bằng DNS rebinding hay redirect. allowPrivate chỉ dùng cho test.
+func NewSafeClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicAddr(ap.Addr()) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, ap.Addr())
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		// Không dùng proxy từ env, nếu không Control sẽ chỉ kiểm tra IP của proxy
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          20,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &http.Client{
+		Transport: otelhttp.NewTransport(transport),
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "https" && req.URL.Scheme != "http" {
+				return errors.New("redirect to unsupported scheme")
+			}
+			return nil
+		},
+	}
+}
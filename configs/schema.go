@@ -0,0 +1,4 @@
+package configs
+
+// SchemaVersion là version schema mà code này yêu cầu, phải khớp bảng schema_version (xem db.sql)
+const SchemaVersion = 21
@@ -0,0 +1,122 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Blob là nội dung đã lưu, hỗ trợ Seek để phục vụ range request
+type Blob struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (*Blob, error)
+	Delete(ctx context.Context, key string) error
+	// Ping kiểm tra storage có truy cập được không (dùng cho readiness)
+	Ping(ctx context.Context) error
+}
+
+var Storage BlobStore
+
+func ConfStorage() {
+	dir := os.Getenv("STORAGE_DIR")
+	if dir == "" {
+		dir = "./storage"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Fatalf("Failed to create storage dir: %v", err)
+	}
+	Storage = &LocalBlobStore{Root: dir}
+}
+
+// LocalBlobStore lưu blob thành file dưới thư mục Root
+type LocalBlobStore struct {
+	Root string
+}
+
+func (s *LocalBlobStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid blob key")
+	}
+	return filepath.Join(s.Root, clean), nil
+}
+
+func (s *LocalBlobStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return 0, err
+	}
+	// Ghi ra file tạm rồi rename để không ai đọc được blob ghi dở
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmp.Name(), p)
+}
+
+func (s *LocalBlobStore) Open(ctx context.Context, key string) (*Blob, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Blob{ReadSeekCloser: f, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (s *LocalBlobStore) Ping(ctx context.Context) error {
+	info, err := os.Stat(s.Root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.New("storage root is not a directory")
+	}
+	f, err := os.CreateTemp(s.Root, ".ping-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
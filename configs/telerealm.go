@@ -2,13 +2,14 @@ package configs
 
 import (
 	"bytes"
+	"context"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"io"
 )
 
-func SendToCloud(filePath string) (*http.Response, error) {
+func SendToCloud(ctx context.Context, filePath string) (*http.Response, error) {
 	apiUrl := os.Getenv("TELEREALM_URI")
 	botToken := os.Getenv("TELEREALM_BOT_TOKEN")
 	chatID := os.Getenv("TELEREALM_CHAT_ID")
@@ -28,19 +29,19 @@ func SendToCloud(filePath string) (*http.Response, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = io.Copy(part, file)
+	size, err := io.Copy(part, file)
 	if err != nil {
 		return nil, err
 	}
+	UploadSizeBytes.WithLabelValues("telerealm").Observe(float64(size))
 	writer.Close()
 
-	req, err := http.NewRequest("POST", apiUrl, &b)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiUrl, &b)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+botToken)
 
-	client := &http.Client{}
-	return client.Do(req)
+	return OutboundClient.Do(req)
 }
\ No newline at end of file
@@ -0,0 +1,64 @@
+package configs
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName dùng cho resource của trace và tên span server
+var ServiceName = "waheim-api"
+
+// OutboundClient là http.Client dùng cho mọi request ra ngoài (Telerealm, GitHub...),
+// tự tạo client span và truyền W3C trace context
+var OutboundClient = &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+
+// ConfTracing cấu hình OpenTelemetry theo OTEL_TRACES_EXPORTER:
+//   - "otlp": gửi qua OTLP/HTTP, endpoint đọc từ OTEL_EXPORTER_OTLP_ENDPOINT
+//   - "stdout": in span ra stdout để kiểm tra local
+//   - để trống hoặc "none": không export nhưng vẫn propagate trace context
+//
+// Trả về hàm shutdown để flush span khi tắt server.
+func ConfTracing() func(context.Context) error {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		ServiceName = name
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")) {
+	case "otlp":
+		exporter, err = otlptracehttp.New(context.Background())
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return func(context.Context) error { return nil }
+	}
+	if err != nil {
+		Logger("configs").Error("failed to create trace exporter", "error", err)
+		return func(context.Context) error { return nil }
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+	)
+	if err != nil {
+		Logger("configs").Warn("failed to build trace resource", "error", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
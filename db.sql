@@ -19,7 +19,8 @@ CREATE TABLE users (
     last_name   TEXT,
     date_of_birth TIMESTAMPTZ,
     gender      TEXT,
-    status      TEXT
+    status      TEXT,
+    purged_at   TIMESTAMPTZ
 );
 
 CREATE TABLE apps (
@@ -37,7 +38,20 @@ CREATE TABLE apps (
     category TEXT,
     tags TEXT[],
     rating DOUBLE PRECISION DEFAULT 0,
-    downloads INT DEFAULT 0
+    downloads INT DEFAULT 0,
+    android_install_uri TEXT NOT NULL DEFAULT '',
+    ios_install_uri TEXT NOT NULL DEFAULT '',
+    theme_color TEXT NOT NULL DEFAULT '',
+    background_color TEXT NOT NULL DEFAULT '',
+    icons JSONB NOT NULL DEFAULT '[]',
+    health_status TEXT NOT NULL DEFAULT 'unknown',
+    health_failures INT NOT NULL DEFAULT 0,
+    health_checked_at TIMESTAMPTZ,
+    rating_count INT NOT NULL DEFAULT 0,
+    rating_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    trending_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ranked_at TIMESTAMPTZ,
+    favorite_count INT NOT NULL DEFAULT 0
 );
 
 CREATE TABLE ratings (
@@ -50,6 +64,342 @@ CREATE TABLE ratings (
     updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
     deleted_at TIMESTAMPTZ,
     status TEXT,
+    helpful_count INT NOT NULL DEFAULT 0,
+    report_count INT NOT NULL DEFAULT 0,
+    -- Lần đầu đánh giá được hiển thị (đã ghi rating.posted); NULL nếu chưa từng published
+    posted_at TIMESTAMPTZ,
     CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id),
     CONSTRAINT fk_app FOREIGN KEY(app_id) REFERENCES apps(id)
-);
\ No newline at end of file
+);
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL UNIQUE,
+    secret_hash TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMPTZ,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    storage_key TEXT,
+    size BIGINT,
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    downloaded_at TIMESTAMPTZ,
+    started_at TIMESTAMPTZ,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE TABLE outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_type TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ
+);
+CREATE INDEX idx_outbox_pending ON outbox (next_attempt_at) WHERE delivered_at IS NULL;
+
+CREATE UNIQUE INDEX idx_ratings_user_app ON ratings (user_id, app_id) WHERE deleted_at IS NULL;
+
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    publisher_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ,
+    CONSTRAINT fk_publisher FOREIGN KEY(publisher_id) REFERENCES users(id)
+);
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    response_code INT,
+    response_body TEXT,
+    error TEXT,
+    duration_ms BIGINT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_subscription FOREIGN KEY(subscription_id) REFERENCES webhook_subscriptions(id),
+    CONSTRAINT uq_delivery_event UNIQUE (subscription_id, event_id)
+);
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE builds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    app_id UUID NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued',
+    workflow_run_id BIGINT,
+    artifact_url TEXT,
+    version_id UUID,
+    logs_url TEXT,
+    error TEXT,
+    artifacts_url TEXT,
+    mirror_attempts INT NOT NULL DEFAULT 0,
+    next_mirror_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    CONSTRAINT fk_app FOREIGN KEY(app_id) REFERENCES apps(id)
+);
+CREATE INDEX idx_builds_app ON builds (app_id, created_at DESC);
+CREATE INDEX idx_builds_mirror ON builds (next_mirror_at) WHERE status = 'queued' AND next_mirror_at IS NOT NULL;
+
+CREATE TABLE app_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    app_id UUID NOT NULL,
+    build_id UUID,
+    source TEXT NOT NULL,
+    apk_key TEXT NOT NULL,
+    apk_sha256 TEXT NOT NULL,
+    apk_size BIGINT NOT NULL,
+    package_name TEXT NOT NULL,
+    version_code BIGINT NOT NULL,
+    version_name TEXT NOT NULL DEFAULT '',
+    min_sdk INT NOT NULL DEFAULT 0,
+    target_sdk INT NOT NULL DEFAULT 0,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    cert_sha256 TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_app FOREIGN KEY(app_id) REFERENCES apps(id),
+    CONSTRAINT fk_build FOREIGN KEY(build_id) REFERENCES builds(id)
+);
+CREATE INDEX idx_app_versions_app ON app_versions (app_id, created_at DESC);
+CREATE INDEX idx_app_versions_package ON app_versions (package_name);
+
+-- Package name Android thuộc về một publisher; PRIMARY KEY chặn hai publisher cùng nhận một package
+CREATE TABLE apk_packages (
+    package_name TEXT PRIMARY KEY,
+    publisher_id UUID NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_publisher FOREIGN KEY(publisher_id) REFERENCES users(id)
+);
+CREATE UNIQUE INDEX idx_apk_packages_owner ON apk_packages (package_name, publisher_id);
+
+CREATE TABLE app_health_checks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    app_id UUID NOT NULL,
+    ok BOOLEAN NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    response_ms BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    checked_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_app FOREIGN KEY(app_id) REFERENCES apps(id)
+);
+CREATE INDEX idx_app_health_checks_app ON app_health_checks (app_id, checked_at DESC);
+
+-- apps.category là slug của categories, apps.tags là slug của tags
+CREATE TABLE categories (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slug TEXT NOT NULL UNIQUE,
+    names JSONB NOT NULL DEFAULT '{}',
+    icon TEXT NOT NULL DEFAULT '',
+    position INT NOT NULL DEFAULT 0,
+    parent_id UUID,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_parent FOREIGN KEY(parent_id) REFERENCES categories(id)
+);
+
+-- Category mặc định theo danh sách categories của Web App Manifest
+INSERT INTO categories (slug, names, position) VALUES
+    ('books', '{"en": "Books", "vi": "Sách"}', 10),
+    ('business', '{"en": "Business", "vi": "Kinh doanh"}', 20),
+    ('education', '{"en": "Education", "vi": "Giáo dục"}', 30),
+    ('entertainment', '{"en": "Entertainment", "vi": "Giải trí"}', 40),
+    ('finance', '{"en": "Finance", "vi": "Tài chính"}', 50),
+    ('fitness', '{"en": "Fitness", "vi": "Thể hình"}', 60),
+    ('food', '{"en": "Food & Drink", "vi": "Ẩm thực"}', 70),
+    ('games', '{"en": "Games", "vi": "Trò chơi"}', 80),
+    ('government', '{"en": "Government", "vi": "Chính phủ"}', 90),
+    ('health', '{"en": "Health", "vi": "Sức khoẻ"}', 100),
+    ('kids', '{"en": "Kids", "vi": "Trẻ em"}', 110),
+    ('lifestyle', '{"en": "Lifestyle", "vi": "Phong cách sống"}', 120),
+    ('magazines', '{"en": "Magazines", "vi": "Tạp chí"}', 130),
+    ('medical', '{"en": "Medical", "vi": "Y tế"}', 140),
+    ('music', '{"en": "Music", "vi": "Âm nhạc"}', 150),
+    ('navigation', '{"en": "Navigation", "vi": "Bản đồ & dẫn đường"}', 160),
+    ('news', '{"en": "News", "vi": "Tin tức"}', 170),
+    ('personalization', '{"en": "Personalization", "vi": "Cá nhân hoá"}', 180),
+    ('photo', '{"en": "Photo & Video", "vi": "Ảnh & video"}', 190),
+    ('politics', '{"en": "Politics", "vi": "Chính trị"}', 200),
+    ('productivity', '{"en": "Productivity", "vi": "Năng suất"}', 210),
+    ('security', '{"en": "Security", "vi": "Bảo mật"}', 220),
+    ('shopping', '{"en": "Shopping", "vi": "Mua sắm"}', 230),
+    ('social', '{"en": "Social", "vi": "Mạng xã hội"}', 240),
+    ('sports', '{"en": "Sports", "vi": "Thể thao"}', 250),
+    ('travel', '{"en": "Travel", "vi": "Du lịch"}', 260),
+    ('utilities', '{"en": "Utilities", "vi": "Tiện ích"}', 270),
+    ('weather', '{"en": "Weather", "vi": "Thời tiết"}', 280);
+
+CREATE TABLE tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_apps_category ON apps (category) WHERE deleted_at IS NULL;
+CREATE INDEX idx_apps_tags ON apps USING GIN (tags);
+CREATE INDEX idx_apps_rating_score ON apps (rating_score DESC) WHERE deleted_at IS NULL;
+CREATE INDEX idx_apps_trending_score ON apps (trending_score DESC) WHERE deleted_at IS NULL;
+
+CREATE TABLE collections (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slug TEXT NOT NULL UNIQUE,
+    titles JSONB NOT NULL DEFAULT '{}',
+    banner_url TEXT NOT NULL DEFAULT '',
+    position INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE collection_apps (
+    collection_id UUID NOT NULL,
+    app_id UUID NOT NULL,
+    position INT NOT NULL,
+    PRIMARY KEY (collection_id, app_id),
+    CONSTRAINT fk_collection FOREIGN KEY(collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    CONSTRAINT fk_app FOREIGN KEY(app_id) REFERENCES apps(id)
+);
+
+CREATE TABLE app_installs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    app_id UUID NOT NULL,
+    user_id UUID,
+    installer TEXT NOT NULL,
+    source TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_app FOREIGN KEY(app_id) REFERENCES apps(id)
+);
+CREATE INDEX idx_app_installs_installer ON app_installs (app_id, installer, source, created_at DESC);
+CREATE INDEX idx_app_installs_app ON app_installs (app_id, created_at DESC);
+CREATE INDEX idx_app_installs_user ON app_installs (user_id, created_at DESC) WHERE user_id IS NOT NULL;
+
+CREATE TABLE user_recommendations (
+    user_id UUID PRIMARY KEY,
+    app_ids UUID[] NOT NULL DEFAULT '{}',
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_ratings_user ON ratings (user_id, created_at DESC) WHERE deleted_at IS NULL;
+
+CREATE TABLE favorites (
+    user_id UUID NOT NULL,
+    app_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, app_id),
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id),
+    CONSTRAINT fk_app FOREIGN KEY(app_id) REFERENCES apps(id)
+);
+CREATE INDEX idx_favorites_user ON favorites (user_id, created_at DESC);
+CREATE INDEX idx_favorites_app ON favorites (app_id, created_at DESC);
+
+-- Lượt "hữu ích" của user cho một đánh giá, ratings.helpful_count là tổng
+CREATE TABLE rating_votes (
+    rating_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (rating_id, user_id),
+    CONSTRAINT fk_rating FOREIGN KEY(rating_id) REFERENCES ratings(id),
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+-- Báo cáo vi phạm; ratings.report_count đếm báo cáo chưa xử lý (status = 'open')
+CREATE TABLE rating_reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rating_id UUID NOT NULL,
+    user_id UUID,
+    reason TEXT NOT NULL,
+    details TEXT,
+    status TEXT NOT NULL DEFAULT 'open',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMPTZ,
+    CONSTRAINT fk_rating FOREIGN KEY(rating_id) REFERENCES ratings(id),
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE UNIQUE INDEX idx_rating_reports_user ON rating_reports (rating_id, user_id);
+CREATE INDEX idx_rating_reports_open ON rating_reports (rating_id) WHERE status = 'open';
+CREATE INDEX idx_ratings_moderation ON ratings (status, report_count DESC, created_at) WHERE deleted_at IS NULL;
+
+-- Mỗi đánh giá có tối đa một phản hồi của publisher của app
+CREATE TABLE rating_replies (
+    rating_id UUID PRIMARY KEY,
+    publisher_id UUID NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_rating FOREIGN KEY(rating_id) REFERENCES ratings(id),
+    CONSTRAINT fk_publisher FOREIGN KEY(publisher_id) REFERENCES users(id)
+);
+
+-- Hộp thư trong app; event_id chống tạo trùng khi event được gửi lại (at-least-once)
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    type TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    data JSONB NOT NULL DEFAULT '{}',
+    event_id UUID,
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_notifications_user ON notifications (user_id, created_at DESC);
+CREATE INDEX idx_notifications_unread ON notifications (user_id) WHERE read_at IS NULL;
+CREATE UNIQUE INDEX idx_notifications_event ON notifications (user_id, event_id) WHERE event_id IS NOT NULL;
+
+-- Chỉ lưu loại thông báo user đã chỉnh, không có dòng nghĩa là bật
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL,
+    type TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, type),
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+-- Lịch sử đăng nhập bằng mật khẩu, có trong data export
+CREATE TABLE user_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    ip TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_user_sessions_user ON user_sessions (user_id, created_at DESC);
+
+-- Tăng version này (và configs.SchemaVersion) mỗi khi thay đổi schema
+CREATE TABLE schema_version (
+    version INT NOT NULL
+);
+INSERT INTO schema_version (version) VALUES (21);
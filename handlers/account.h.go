@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"waheim.api/configs"
+	"waheim.api/services"
+)
+
+var accountService = services.NewAccountService()
+
+func DeactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	if id == "" {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	role, _ := r.Context().Value("role").(string)
+	if role != "admin" && userID != id {
+		http.Error(w, "Permission denied", http.StatusForbidden)
+		return
+	}
+	if err := accountService.Deactivate(r.Context(), id, role == "admin" && userID != id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func ActivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	if id == "" {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
+		return
+	}
+	if err := accountService.Activate(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func RestoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	if id == "" {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
+		return
+	}
+	if err := accountService.Restore(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
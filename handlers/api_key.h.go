@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/services"
+)
+
+var apiKeyService = services.NewApiKeyService()
+
+type createApiKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func CreateApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	// Không cho dùng API key để sinh thêm API key
+	if method, _ := r.Context().Value("auth_method").(string); method == "api_key" {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_API_KEY_NOT_ALLOWED), http.StatusForbidden)
+		return
+	}
+	var req createApiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	key, err := apiKeyService.CreateApiKey(r.Context(), userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+func GetApiKeysHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	keys, err := apiKeyService.GetApiKeys(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+func RevokeApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if method, _ := r.Context().Value("auth_method").(string); method == "api_key" {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_API_KEY_NOT_ALLOWED), http.StatusForbidden)
+		return
+	}
+	id := pathParam(r, "id")
+	if id == "" {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	err := apiKeyService.RevokeApiKey(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
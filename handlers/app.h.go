@@ -1,22 +1,23 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"waheim.api/configs"
 	"waheim.api/models"
 	"waheim.api/services"
 )
 
-var appService = services.NewAppService()
+var (
+	appService        = services.NewAppService()
+	buildService      = services.NewBuildService()
+	appVersionService = services.NewAppVersionService()
+	pwaService        = services.NewPwaService()
+	monitorService    = services.NewMonitorService()
+)
 
 func CreateAppHandler(w http.ResponseWriter, r *http.Request) {
 	var appReq map[string]interface{}
@@ -55,12 +56,20 @@ func CreateAppHandler(w http.ResponseWriter, r *http.Request) {
 	if v, ok := appReq["category"].(string); ok {
 		app.Category = v
 	}
-	if v, ok := appReq["rating"].(float64); ok {
-		app.Rating = v
+	if v, ok := appReq["theme_color"].(string); ok {
+		app.ThemeColor = v
+	}
+	if v, ok := appReq["background_color"].(string); ok {
+		app.BackgroundColor = v
 	}
-	if v, ok := appReq["downloads"].(float64); ok {
-		app.Downloads = int(v)
+	if v, ok := appReq["icons"]; ok {
+		data, _ := json.Marshal(v)
+		if err := json.Unmarshal(data, &app.Icons); err != nil {
+			http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_APP_ICON), http.StatusBadRequest)
+			return
+		}
 	}
+	// rating và downloads do hệ thống tính, không lấy từ request
 	if v, ok := appReq["screenshots"].([]interface{}); ok {
 		for _, s := range v {
 			if str, ok := s.(string); ok {
@@ -76,112 +85,33 @@ func CreateAppHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	err := appService.CreateApp(&app)
+	err := appService.CreateApp(r.Context(), &app)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Gọi GitHub API để build APK
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	repoOwner := os.Getenv("GITHUB_REPO_OWNER")
-	repoName := os.Getenv("GITHUB_REPO_NAME")
-	apiUrl := fmt.Sprintf("https://api.github.com/repos/%s/%s/dispatches", repoOwner, repoName)
-	apkUrl := ""
-	// Dùng app.Uri làm url web build APK
-	payload := fmt.Sprintf(`{"event_type":"build-apk","client_payload":{"url":"%s"}}`, app.Uri)
-	req, _ := http.NewRequest("POST", apiUrl, bytes.NewBuffer([]byte(payload)))
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("Authorization", "Bearer "+githubToken)
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil || resp.StatusCode >= 300 {
+	// Build APK chạy trên GitHub Actions, kết quả về qua POST /webhooks/github
+	if _, err := buildService.Dispatch(r.Context(), app); err != nil {
 		// Không build được thì vẫn trả app, chỉ log lỗi
-		if err == nil {
-			body, _ := ioutil.ReadAll(resp.Body)
-			err = fmt.Errorf("github dispatch failed: %s", string(body))
-		}
-		fmt.Println("Error triggering GitHub build:", err)
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(app)
-		return
-	}
-	// Đợi workflow build xong và lấy artifact (polling đơn giản, thực tế nên dùng async)
-	runId := ""
-	for i := 0; i < 20; i++ { // poll tối đa 20 lần, mỗi lần 15s
-		time.Sleep(15 * time.Second)
-		// Lấy workflow runs
-		runsReq, _ := http.NewRequest("GET",
-			fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/runs?event=repository_dispatch", repoOwner, repoName), nil)
-		runsReq.Header.Set("Authorization", "Bearer "+githubToken)
-		runsResp, err := client.Do(runsReq)
-		if err != nil {
-			continue
-		}
-		runsBody, _ := ioutil.ReadAll(runsResp.Body)
-		runsStr := string(runsBody)
-		// Tìm runId mới nhất
-		idx := strings.Index(runsStr, "\"id\":")
-		if idx > 0 {
-			runsStr = runsStr[idx+6:]
-			end := strings.IndexAny(runsStr, ",}")
-			if end > 0 {
-				runId = runsStr[:end]
-			}
-		}
-		if runId != "" {
-			// Kiểm tra run đã success chưa
-			checkReq, _ := http.NewRequest("GET",
-				fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/runs/%s", repoOwner, repoName, runId), nil)
-			checkReq.Header.Set("Authorization", "Bearer "+githubToken)
-			checkResp, err := client.Do(checkReq)
-			if err != nil {
-				continue
-			}
-			checkBody, _ := ioutil.ReadAll(checkResp.Body)
-			if strings.Contains(string(checkBody), "\"conclusion\":\"success\"") {
-				// Lấy artifact
-				artReq, _ := http.NewRequest("GET",
-					fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/runs/%s/artifacts", repoOwner, repoName, runId), nil)
-				artReq.Header.Set("Authorization", "Bearer "+githubToken)
-				artResp, err := client.Do(artReq)
-				if err != nil {
-					break
-				}
-				artBody, _ := ioutil.ReadAll(artResp.Body)
-				// Tìm url download
-				idx := strings.Index(string(artBody), "archive_download_url")
-				if idx > 0 {
-					artBodyStr := string(artBody)[idx+23:]
-					end := strings.IndexAny(artBodyStr, "\",}")
-					if end > 0 {
-						apkUrl = artBodyStr[:end]
-					}
-				}
-				break
-			}
-		}
-	}
-	if apkUrl != "" {
-		// Update AndroidInstallUri cho app
-		updates := map[string]interface{}{"android_install_uri": apkUrl}
-		_ = appService.UpdateApp(app.Id, updates)
-		app.AndroidInstallUri = apkUrl
+		logger.ErrorContext(r.Context(), "triggering GitHub build failed", "app_id", app.Id, "error", err)
 	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(app)
 }
 
 func UpdateAppHandler(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := pathParam(r, "id")
+	if id == "" {
+		id = r.URL.Query().Get("id")
+	}
 	if id == "" {
 		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
 		return
 	}
 	userID, _ := r.Context().Value("user_id").(string)
 	role, _ := r.Context().Value("role").(string)
-	app, err := appService.GetAppById(id)
+	app, err := appService.GetAppById(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -195,23 +125,33 @@ func UpdateAppHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
 		return
 	}
-	err = appService.UpdateApp(id, updates)
+	err = appService.UpdateApp(r.Context(), id, updates)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// Đổi Uri thì APK cũ không còn đúng site, build lại như lúc tạo app
+	if uri, ok := updates["uri"].(string); ok && uri != app.Uri {
+		app.Uri = uri
+		if _, err := buildService.Dispatch(r.Context(), app); err != nil {
+			logger.ErrorContext(r.Context(), "triggering GitHub build failed", "app_id", app.Id, "error", err)
+		}
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
 func DeleteAppHandler(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := pathParam(r, "id")
+	if id == "" {
+		id = r.URL.Query().Get("id")
+	}
 	if id == "" {
 		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
 		return
 	}
 	userID, _ := r.Context().Value("user_id").(string)
 	role, _ := r.Context().Value("role").(string)
-	app, err := appService.GetAppById(id)
+	app, err := appService.GetAppById(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -220,7 +160,7 @@ func DeleteAppHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Permission denied", http.StatusForbidden)
 		return
 	}
-	err = appService.DeleteApp(id)
+	err = appService.DeleteApp(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -229,16 +169,30 @@ func DeleteAppHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetAppByIdHandler(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := pathParam(r, "id")
+	if id == "" {
+		id = r.URL.Query().Get("id")
+	}
 	if id == "" {
 		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
 		return
 	}
-	app, err := appService.GetAppById(id)
+	app, err := appService.GetAppById(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	if version, err := appVersionService.GetLatest(r.Context(), id); err == nil {
+		app.Android = &version
+	}
+	if uptime, err := monitorService.Uptime(r.Context(), app); err == nil {
+		app.Uptime = &uptime
+	}
+	if userID, _ := r.Context().Value("user_id").(string); userID != "" {
+		if favorite, err := favoriteService.IsFavorite(r.Context(), userID, id); err == nil {
+			app.IsFavorite = &favorite
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(app)
 }
@@ -256,11 +210,121 @@ func GetAllAppsHandler(w http.ResponseWriter, r *http.Request) {
 			offset = parsed
 		}
 	}
-	apps, err := appService.GetAllApps(limit, offset)
+	apps, err := appService.GetAllApps(r.Context(), limit, offset, r.URL.Query().Get("sort"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if err.Error() == configs.GetErrString(configs.ErrorCode_INVALID_REQUEST) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(apps)
 }
+
+// DownloadAndroidHandler phục vụ APK mới nhất của app; http.ServeContent xử lý Range và If-None-Match
+func DownloadAndroidHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	version, blob, err := appVersionService.OpenLatest(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer blob.Close()
+	// Request Range tiếp tục tải dở không tính là lượt cài mới
+	if rng := r.Header.Get("Range"); rng == "" || strings.HasPrefix(rng, "bytes=0-") {
+		userID, _ := r.Context().Value("user_id").(string)
+		if _, err := appService.RecordInstall(r.Context(), id, userID, clientIP(r), models.InstallSourceAndroid); err != nil {
+			logger.ErrorContext(r.Context(), "recording install failed", "app_id", id, "error", err)
+		}
+	}
+	w.Header().Set("Content-Type", "application/vnd.android.package-archive")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+version.PackageName+`.apk"`)
+	w.Header().Set("ETag", `"`+version.ApkSha256+`"`)
+	w.Header().Set("X-Checksum-Sha256", version.ApkSha256)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	http.ServeContent(w, r, version.PackageName+".apk", blob.ModTime, blob)
+}
+
+// RecordWebInstallHandler được client gọi khi user cài web app (sự kiện appinstalled).
+// Mỗi user (hoặc IP nếu ẩn danh) chỉ được tính một lượt cài mỗi app trong INSTALL_DEDUPE_HOURS.
+func RecordWebInstallHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	install, err := appService.RecordInstall(r.Context(), pathParam(r, "id"), userID, clientIP(r), models.InstallSourceWeb)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// Lượt cài trùng trong window trả về bản ghi đã có với 200
+	if install.Recorded {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(install)
+}
+
+// UploadApkHandler nhận APK thô trong body (application/vnd.android.package-archive)
+func UploadApkHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	userID, _ := r.Context().Value("user_id").(string)
+	role, _ := r.Context().Value("role").(string)
+	app, err := appService.GetAppById(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if role != "admin" && app.PublisherId != userID {
+		http.Error(w, "Permission denied", http.StatusForbidden)
+		return
+	}
+	version, err := appVersionService.Upload(r.Context(), app, r.Body)
+	if err != nil {
+		// APK hỏng hoặc body lỗi là lỗi của client; lỗi storage/DB trả về 500
+		status := http.StatusInternalServerError
+		switch {
+		case err.Error() == configs.GetErrString(configs.ErrorCode_APK_PACKAGE_CONFLICT):
+			status = http.StatusConflict
+		case strings.HasPrefix(err.Error(), configs.GetErrString(configs.ErrorCode_INVALID_APK)),
+			strings.HasPrefix(err.Error(), configs.GetErrString(configs.ErrorCode_INVALID_REQUEST)):
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(version)
+}
+
+// WebManifestHandler trả về Web App Manifest của app. Route đăng ký ngoài CORS middleware
+// để site của publisher có thể link tới manifest này.
+func WebManifestHandler(w http.ResponseWriter, r *http.Request) {
+	app, err := appService.GetAppById(r.Context(), pathParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(pwaService.Manifest(app))
+}
+
+// ServiceWorkerHandler trả về service worker khởi đầu để publisher tự host trên site của mình
+func ServiceWorkerHandler(w http.ResponseWriter, r *http.Request) {
+	app, err := appService.GetAppById(r.Context(), pathParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	sw, err := pwaService.ServiceWorker(app)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="sw.js"`)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(sw)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"waheim.api/configs"
+	"waheim.api/services"
+)
+
+var catalogService = services.NewCatalogService()
+
+// requestLocale lấy locale từ ?locale=, không có thì từ ngôn ngữ đầu tiên của Accept-Language
+func requestLocale(r *http.Request) string {
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		locale, _, _ = strings.Cut(r.Header.Get("Accept-Language"), ",")
+		locale, _, _ = strings.Cut(locale, ";")
+		locale, _, _ = strings.Cut(locale, "-")
+	}
+	return strings.ToLower(strings.TrimSpace(locale))
+}
+
+func GetCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories, err := catalogService.GetCategories(r.Context(), requestLocale(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+func CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	category, err := catalogService.CreateCategory(r.Context(), input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+func UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	category, err := catalogService.UpdateCategory(r.Context(), pathParam(r, "id"), input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	if err := catalogService.DeleteCategory(r.Context(), pathParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func GetTagsHandler(w http.ResponseWriter, r *http.Request) {
+	tags, err := catalogService.GetTags(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+func CreateTagHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.TagInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	tag, err := catalogService.CreateTag(r.Context(), input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+func UpdateTagHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.TagInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	tag, err := catalogService.UpdateTag(r.Context(), pathParam(r, "id"), input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+func DeleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	if err := catalogService.DeleteTag(r.Context(), pathParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
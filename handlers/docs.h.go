@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/services"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// Các struct dưới đây chỉ dùng để mô tả body cho những handler decode vào map
+type signUpRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Password string `json:"password"`
+	Address  string `json:"address,omitempty"`
+}
+
+type signInRequest struct {
+	WaheimId string `json:"waheim_id"`
+	Password string `json:"password"`
+}
+
+type tokenResponse struct {
+	Token string `json:"token"`
+}
+
+// RouteDocs là metadata của từng route (summary, auth, query, schema request/response), tra theo
+// method và path Gin. Danh sách path/method trong spec lấy từ router qua SetOpenAPIRoutes; mỗi route
+// đăng ký phải có đúng một entry ở đây (main_test.go kiểm tra điều này)
+var RouteDocs = []configs.RouteDoc{
+	{Method: "GET", Path: "/healthz", Summary: "Liveness check", Tag: "system", Response: services.HealthReport{}},
+	{Method: "GET", Path: "/readyz", Summary: "Readiness check (fails while shutting down)", Tag: "system", Response: services.HealthReport{}},
+	{Method: "GET", Path: "/metrics", Summary: "Prometheus metrics (Bearer METRICS_TOKEN if configured)", Tag: "system", Response: "", ContentType: "text/plain"},
+	{Method: "GET", Path: "/openapi.json", Summary: "OpenAPI document", Tag: "system", Response: map[string]interface{}{}},
+	{Method: "GET", Path: "/docs", Summary: "API documentation UI", Tag: "system", Response: "", ContentType: "text/html"},
+	{Method: "GET", Path: "/.well-known/jwks.json", Summary: "Public keys for verifying Waheim JWTs", Tag: "auth", Response: map[string]interface{}{}},
+	{Method: "POST", Path: "/webhooks/github", Summary: "GitHub workflow_run webhook; records the run and mirrors the APK in the background (X-Hub-Signature-256)", Tag: "system", Request: services.GitHubWorkflowRunEvent{}, Status: http.StatusAccepted},
+
+	{Method: "POST", Path: "/auth/sign-up", Summary: "Create an account", Tag: "auth", Request: signUpRequest{}, Status: http.StatusCreated},
+	{Method: "POST", Path: "/auth/sign-in", Summary: "Sign in and receive a JWT", Tag: "auth", Request: signInRequest{}, Response: tokenResponse{}},
+	{Method: "POST", Path: "/auth/sign-out", Summary: "Clear the auth cookie", Tag: "auth", Auth: true},
+	{Method: "GET", Path: "/auth/me", Summary: "Current user", Tag: "auth", Auth: true, Response: models.User{}},
+	{Method: "POST", Path: "/auth/api-keys", Summary: "Create a personal API key", Tag: "auth", Auth: true, Request: createApiKeyRequest{}, Response: models.ApiKey{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/auth/api-keys", Summary: "List active API keys", Tag: "auth", Auth: true, Response: []models.ApiKey{}},
+	{Method: "DELETE", Path: "/auth/api-keys/:id", Summary: "Revoke an API key", Tag: "auth", Auth: true},
+
+	{Method: "GET", Path: "/exports/:id/download", Summary: "Download a data export through its signed link", Tag: "user", Query: []string{"expires", "sig"}, Response: []byte{}, ContentType: "application/zip"},
+	{Method: "POST", Path: "/user/me/export", Summary: "Request a personal data export (profile, apps, ratings, API keys, favorites, sessions, installs)", Tag: "user", Auth: true, Response: models.DataExport{}, Status: http.StatusAccepted},
+	{Method: "GET", Path: "/user/me/export/:id", Summary: "Data export status and download link", Tag: "user", Auth: true, Response: models.DataExport{}},
+	{Method: "GET", Path: "/user/me/favorites", Summary: "List favorite apps, most recently added first", Tag: "user", Auth: true, Query: []string{"limit", "offset"}, Response: []models.App{}},
+	{Method: "PUT", Path: "/user/me/favorites/:appId", Summary: "Add an app to favorites (idempotent)", Tag: "user", Auth: true},
+	{Method: "DELETE", Path: "/user/me/favorites/:appId", Summary: "Remove an app from favorites", Tag: "user", Auth: true},
+	{Method: "GET", Path: "/user/me/notifications", Summary: "List notifications, newest first, with the unread count (limit 1-100, default 20)", Tag: "user", Auth: true, Query: []string{"unread", "limit", "offset"}, Response: services.NotificationList{}},
+	{Method: "POST", Path: "/user/me/notifications/read-all", Summary: "Mark all notifications as read", Tag: "user", Auth: true, Response: markAllReadResponse{}},
+	{Method: "POST", Path: "/user/me/notifications/:notificationId/read", Summary: "Mark a notification as read", Tag: "user", Auth: true},
+	{Method: "GET", Path: "/user/me/notification-preferences", Summary: "Enabled state of each notification type", Tag: "user", Auth: true, Response: map[string]bool{}},
+	{Method: "PUT", Path: "/user/me/notification-preferences", Summary: "Enable or disable notification types (security alerts cannot be disabled)", Tag: "user", Auth: true, Request: map[string]bool{}, Response: map[string]bool{}},
+	{Method: "GET", Path: "/user/me/recommendations", Summary: "Personalized app recommendations from install, rating and favorite history", Tag: "user", Auth: true, Query: []string{"limit", "offset"}, Response: []models.App{}},
+	{Method: "GET", Path: "/user/:id", Summary: "Get a user (admin)", Tag: "user", Auth: true, Response: models.User{}},
+	{Method: "PUT", Path: "/user/:id", Summary: "Update a user", Tag: "user", Auth: true, Request: map[string]interface{}{}},
+	{Method: "DELETE", Path: "/user/:id", Summary: "Soft delete a user", Tag: "user", Auth: true},
+	{Method: "POST", Path: "/user/:id/deactivate", Summary: "Deactivate an account (signing in again reactivates a self-deactivated account)", Tag: "user", Auth: true},
+	{Method: "POST", Path: "/user/:id/activate", Summary: "Reactivate an account (admin)", Tag: "user", Auth: true},
+	{Method: "POST", Path: "/user/:id/restore", Summary: "Restore a deleted account within the grace period (admin)", Tag: "user", Auth: true},
+
+	{Method: "GET", Path: "/app/:id", Summary: "Get an app with its latest Android version, uptime and is_favorite (when signed in)", Tag: "app", Response: models.App{}},
+	{Method: "GET", Path: "/app", Summary: "List apps; sort=top (Bayesian rating) or sort=trending (recent activity)", Tag: "app", Query: []string{"limit", "offset", "sort"}, Response: []models.App{}},
+	{Method: "POST", Path: "/app", Summary: "Create an app and dispatch its APK build", Tag: "app", Auth: true, Request: models.App{}, Response: models.App{}, Status: http.StatusCreated},
+	{Method: "PUT", Path: "/app/:id", Summary: "Update an app (name, description, uri, icon, screenshots, category, tags, theme_color, background_color, icons, status)", Tag: "app", Auth: true, Request: map[string]interface{}{}},
+	{Method: "DELETE", Path: "/app/:id", Summary: "Delete an app", Tag: "app", Auth: true},
+	{Method: "GET", Path: "/app/:id/download/android", Summary: "Download the latest APK (supports Range requests)", Tag: "app", Response: []byte{}, ContentType: "application/vnd.android.package-archive"},
+	{Method: "POST", Path: "/app/:id/installs", Summary: "Report a web app install (auth optional); repeats by the same user or IP within INSTALL_DEDUPE_HOURS are not counted", Tag: "app", Response: models.AppInstall{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/app/:id/manifest.webmanifest", Summary: "Web App Manifest generated from the app listing", Tag: "app", Response: services.WebManifest{}, ContentType: "application/manifest+json"},
+	{Method: "GET", Path: "/app/:id/sw.js", Summary: "Starter service worker to host on the app's site", Tag: "app", Response: "", ContentType: "text/javascript"},
+	{Method: "POST", Path: "/app/:id/android", Summary: "Upload a signed APK as the app's latest Android version", Tag: "app", Auth: true, Request: []byte{}, RequestContentType: "application/vnd.android.package-archive", Response: models.AppVersion{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/app/:id/similar", Summary: "Apps similar by category, tags and co-installs", Tag: "app", Query: []string{"limit"}, Response: []models.App{}},
+	{Method: "GET", Path: "/app/:id/ratings", Summary: "List ratings of an app with publisher replies", Tag: "rating", Query: []string{"limit", "offset"}, Response: []models.Rating{}},
+	{Method: "POST", Path: "/app/:id/ratings", Summary: "Rate an app; comments caught by the content filter wait for moderation", Tag: "rating", Auth: true, Request: postRatingRequest{}, Response: models.Rating{}, Status: http.StatusCreated},
+	{Method: "PUT", Path: "/app/:id/ratings/:ratingId/helpful", Summary: "Mark a review as helpful", Tag: "rating", Auth: true, Response: models.Rating{}},
+	{Method: "DELETE", Path: "/app/:id/ratings/:ratingId/helpful", Summary: "Remove a helpful mark", Tag: "rating", Auth: true, Response: models.Rating{}},
+	{Method: "POST", Path: "/app/:id/ratings/:ratingId/reports", Summary: "Report a review (spam, offensive, off_topic, fake, other)", Tag: "rating", Auth: true, Request: reportRatingRequest{}, Response: models.RatingReport{}, Status: http.StatusCreated},
+	{Method: "PUT", Path: "/app/:id/ratings/:ratingId/reply", Summary: "Post or edit the publisher's reply to a review; the reviewer is notified", Tag: "rating", Auth: true, Request: ratingReplyRequest{}, Response: models.RatingReply{}},
+	{Method: "DELETE", Path: "/app/:id/ratings/:ratingId/reply", Summary: "Delete the publisher's reply to a review", Tag: "rating", Auth: true},
+
+	{Method: "GET", Path: "/categories", Summary: "List categories with published app counts", Tag: "catalog", Query: []string{"locale"}, Response: []models.Category{}},
+	{Method: "GET", Path: "/tags", Summary: "List the tag vocabulary with usage counts", Tag: "catalog", Response: []models.Tag{}},
+	{Method: "POST", Path: "/admin/categories", Summary: "Create a category (admin)", Tag: "catalog", Auth: true, Request: services.CategoryInput{}, Response: models.Category{}, Status: http.StatusCreated},
+	{Method: "PUT", Path: "/admin/categories/:id", Summary: "Update a category; renaming the slug updates apps (admin)", Tag: "catalog", Auth: true, Request: services.CategoryInput{}, Response: models.Category{}},
+	{Method: "DELETE", Path: "/admin/categories/:id", Summary: "Delete an unused category (admin)", Tag: "catalog", Auth: true},
+	{Method: "POST", Path: "/admin/tags", Summary: "Add a tag to the vocabulary (admin)", Tag: "catalog", Auth: true, Request: services.TagInput{}, Response: models.Tag{}, Status: http.StatusCreated},
+	{Method: "PUT", Path: "/admin/tags/:id", Summary: "Rename a tag; apps follow the new slug (admin)", Tag: "catalog", Auth: true, Request: services.TagInput{}, Response: models.Tag{}},
+	{Method: "DELETE", Path: "/admin/tags/:id", Summary: "Delete a tag and remove it from apps (admin)", Tag: "catalog", Auth: true},
+
+	{Method: "GET", Path: "/storefront", Summary: "Store home page: scheduled curated collections followed by top rated, trending and new releases", Tag: "storefront", Query: []string{"locale"}, Response: services.Storefront{}},
+	{Method: "GET", Path: "/admin/collections", Summary: "List curated collections including unscheduled ones (admin)", Tag: "storefront", Auth: true, Query: []string{"locale"}, Response: []models.Collection{}},
+	{Method: "POST", Path: "/admin/collections", Summary: "Create a curated collection (admin)", Tag: "storefront", Auth: true, Request: services.CollectionInput{}, Response: models.Collection{}, Status: http.StatusCreated},
+	{Method: "PUT", Path: "/admin/collections/:id", Summary: "Replace a collection's title, banner, position and schedule (admin)", Tag: "storefront", Auth: true, Request: services.CollectionInput{}, Response: models.Collection{}},
+	{Method: "DELETE", Path: "/admin/collections/:id", Summary: "Delete a collection (admin)", Tag: "storefront", Auth: true},
+	{Method: "PUT", Path: "/admin/collections/:id/apps", Summary: "Set the ordered apps of a collection (admin)", Tag: "storefront", Auth: true, Request: setCollectionAppsRequest{}},
+	{Method: "GET", Path: "/admin/ratings", Summary: "Review moderation queue by status, default pending (admin)", Tag: "rating", Auth: true, Query: []string{"status", "limit", "offset"}, Response: []models.RatingModeration{}},
+	{Method: "PUT", Path: "/admin/ratings/:id/status", Summary: "Publish or hide a review and resolve its reports (admin)", Tag: "rating", Auth: true, Request: moderateRatingRequest{}, Response: models.Rating{}},
+
+	{Method: "POST", Path: "/publisher/webhooks", Summary: "Subscribe to app, build and rating events (secret is only returned here)", Tag: "webhook", Auth: true, Request: createWebhookRequest{}, Response: models.WebhookSubscription{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/publisher/webhooks", Summary: "List webhook subscriptions", Tag: "webhook", Auth: true, Response: []models.WebhookSubscription{}},
+	{Method: "PUT", Path: "/publisher/webhooks/:id", Summary: "Update a webhook subscription", Tag: "webhook", Auth: true, Request: updateWebhookRequest{}, Response: models.WebhookSubscription{}},
+	{Method: "DELETE", Path: "/publisher/webhooks/:id", Summary: "Delete a webhook subscription", Tag: "webhook", Auth: true},
+	{Method: "GET", Path: "/publisher/webhooks/:id/deliveries", Summary: "Delivery log with response codes", Tag: "webhook", Auth: true, Query: []string{"limit", "offset"}, Response: []models.WebhookDelivery{}},
+	{Method: "POST", Path: "/publisher/webhooks/:id/test", Summary: "Send a webhook.test event immediately", Tag: "webhook", Auth: true, Response: models.WebhookDelivery{}},
+}
+
+// openAPIDescription nói rõ phần nào của spec được sinh tự động, phần nào viết tay
+const openAPIDescription = "Paths, methods and path parameters are generated from the routes registered on the router. " +
+	"Summaries, authentication, query parameters and request/response schemas come from hand-written per-route metadata " +
+	"and are not checked against the handlers."
+
+var openAPISpec map[string]interface{}
+
+// SetOpenAPIRoutes sinh spec từ các route đã đăng ký trên router, gắn metadata trong RouteDocs.
+// Route chưa có metadata vẫn xuất hiện trong spec với summary "Undocumented route".
+func SetOpenAPIRoutes(routes gin.RoutesInfo) {
+	docs := make(map[string]configs.RouteDoc, len(RouteDocs))
+	for _, doc := range RouteDocs {
+		docs[doc.Method+" "+doc.Path] = doc
+	}
+	merged := make([]configs.RouteDoc, 0, len(routes))
+	for _, route := range routes {
+		doc, ok := docs[route.Method+" "+route.Path]
+		if !ok {
+			doc = configs.RouteDoc{Summary: "Undocumented route"}
+		}
+		doc.Method, doc.Path = route.Method, route.Path
+		merged = append(merged, doc)
+	}
+	spec := configs.BuildOpenAPI("Waheim API", "1.0.0", merged)
+	if info, ok := spec["info"].(map[string]string); ok {
+		info["description"] = openAPIDescription
+	}
+	openAPISpec = spec
+}
+
+func OpenAPISpec() map[string]interface{} {
+	return openAPISpec
+}
+
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAPISpec)
+}
+
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
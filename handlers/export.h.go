@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"waheim.api/configs"
+	"waheim.api/services"
+)
+
+var exportService = services.NewExportService()
+
+func RequestExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	export, err := exportService.RequestExport(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(export)
+}
+
+func GetExportHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	if id == "" {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	export, err := exportService.GetExport(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(export)
+}
+
+// DownloadExportHandler không yêu cầu đăng nhập, quyền truy cập nằm ở chữ ký của link
+func DownloadExportHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	blob, err := exportService.Download(r.Context(), id, r.URL.Query().Get("expires"), r.URL.Query().Get("sig"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == configs.GetErrString(configs.ErrorCode_EXPORT_LINK_INVALID) {
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	defer blob.Close()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="waheim-export-`+id+`.zip"`)
+	w.Header().Set("Content-Length", strconv.FormatInt(blob.Size, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	io.Copy(w, blob)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"waheim.api/configs"
+	"waheim.api/services"
+)
+
+// GitHubWebhookHandler nhận webhook của GitHub (workflow_run) để hoàn tất build APK.
+// Body được xác thực bằng X-Hub-Signature-256 với GITHUB_WEBHOOK_SECRET.
+func GitHubWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 5<<20))
+	if err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	if !buildService.VerifySignature(body, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_WEBHOOK_SIGNATURE), http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("X-GitHub-Event") != "workflow_run" {
+		// ping và các event khác: chỉ xác nhận đã nhận
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	var event services.GitHubWorkflowRunEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	// Chỉ ghi nhận run; APK được worker "build mirror" tải sau nên trả 202
+	if err := buildService.HandleWorkflowRun(r.Context(), event); err != nil {
+		// GitHub không tự gửi lại, nhưng 5xx hiện trong mục Recent Deliveries để redeliver tay
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"waheim.api/services"
+)
+
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, services.Health.Liveness(r.Context()))
+}
+
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, services.Health.Readiness(r.Context()))
+}
+
+func writeHealthReport(w http.ResponseWriter, report services.HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"waheim.api/configs"
+)
+
+var metricsHandler = promhttp.HandlerFor(configs.Metrics, promhttp.HandlerOpts{})
+
+// MetricsHandler phục vụ /metrics; nếu đặt METRICS_TOKEN thì yêu cầu Bearer token tương ứng
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if token := os.Getenv("METRICS_TOKEN"); token != "" {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_TOKEN), http.StatusUnauthorized)
+			return
+		}
+	}
+	metricsHandler.ServeHTTP(w, r)
+}
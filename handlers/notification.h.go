@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"waheim.api/configs"
+	"waheim.api/services"
+)
+
+var notificationService = services.NewNotificationService()
+
+type markAllReadResponse struct {
+	Updated int64 `json:"updated"`
+}
+
+func GetNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	offset := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+	userID, _ := r.Context().Value("user_id").(string)
+	list, err := notificationService.List(r.Context(), userID, unreadOnly, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func MarkNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	if err := notificationService.MarkRead(r.Context(), userID, pathParam(r, "notificationId")); err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case configs.GetErrString(configs.ErrorCode_INVALID_REQUEST):
+			status = http.StatusBadRequest
+		case configs.GetErrString(configs.ErrorCode_NOTIFICATION_NOT_FOUND):
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func MarkAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	updated, err := notificationService.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(markAllReadResponse{Updated: updated})
+}
+
+func GetNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	prefs, err := notificationService.Preferences(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// SetNotificationPreferencesHandler nhận map loại thông báo -> bật/tắt, loại không truyền giữ nguyên
+func SetNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	var req map[string]bool
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	prefs, err := notificationService.SetPreferences(r.Context(), userID, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"waheim.api/configs"
+	"waheim.api/services"
+)
+
+var ratingService = services.NewRatingService()
+
+type postRatingRequest struct {
+	Stars   int    `json:"stars"`
+	Comment string `json:"comment,omitempty"`
+}
+
+func PostRatingHandler(w http.ResponseWriter, r *http.Request) {
+	appId := pathParam(r, "id")
+	if appId == "" {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
+		return
+	}
+	var req postRatingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	rating, err := ratingService.PostRating(r.Context(), userID, appId, req.Stars, req.Comment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rating)
+}
+
+func GetAppRatingsHandler(w http.ResponseWriter, r *http.Request) {
+	appId := pathParam(r, "id")
+	limit := 10
+	offset := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+	ratings, err := ratingService.GetAppRatings(r.Context(), appId, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ratings)
+}
+
+type reportRatingRequest struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details,omitempty"`
+}
+
+type ratingReplyRequest struct {
+	Body string `json:"body"`
+}
+
+type moderateRatingRequest struct {
+	Status string `json:"status"`
+}
+
+// ratingErrorStatus chọn HTTP status cho lỗi của vote/báo cáo/kiểm duyệt đánh giá
+func ratingErrorStatus(err error) int {
+	switch err.Error() {
+	case configs.GetErrString(configs.ErrorCode_RATING_NOT_FOUND):
+		return http.StatusNotFound
+	case configs.GetErrString(configs.ErrorCode_RATING_ALREADY_REPORTED):
+		return http.StatusConflict
+	case configs.GetErrString(configs.ErrorCode_RATING_IS_OWN), configs.GetErrString(configs.ErrorCode_RATING_REPLY_FORBIDDEN):
+		return http.StatusForbidden
+	case configs.GetErrString(configs.ErrorCode_DATABASE_ERROR):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+func setRatingHelpful(w http.ResponseWriter, r *http.Request, helpful bool) {
+	userID, _ := r.Context().Value("user_id").(string)
+	rating, err := ratingService.SetHelpful(r.Context(), userID, pathParam(r, "id"), pathParam(r, "ratingId"), helpful)
+	if err != nil {
+		http.Error(w, err.Error(), ratingErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rating)
+}
+
+func AddHelpfulVoteHandler(w http.ResponseWriter, r *http.Request) {
+	setRatingHelpful(w, r, true)
+}
+
+func RemoveHelpfulVoteHandler(w http.ResponseWriter, r *http.Request) {
+	setRatingHelpful(w, r, false)
+}
+
+func ReportRatingHandler(w http.ResponseWriter, r *http.Request) {
+	var req reportRatingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	report, err := ratingService.Report(r.Context(), userID, pathParam(r, "id"), pathParam(r, "ratingId"), req.Reason, req.Details)
+	if err != nil {
+		http.Error(w, err.Error(), ratingErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// ReplyRatingHandler tạo hoặc sửa phản hồi; chỉ publisher của app được phản hồi
+func ReplyRatingHandler(w http.ResponseWriter, r *http.Request) {
+	var req ratingReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	reply, err := ratingService.Reply(r.Context(), userID, pathParam(r, "id"), pathParam(r, "ratingId"), req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), ratingErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reply)
+}
+
+func DeleteRatingReplyHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	if err := ratingService.DeleteReply(r.Context(), userID, pathParam(r, "id"), pathParam(r, "ratingId")); err != nil {
+		http.Error(w, err.Error(), ratingErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func GetRatingModerationQueueHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	offset := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+	items, err := ratingService.ModerationQueue(r.Context(), r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), ratingErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+func ModerateRatingHandler(w http.ResponseWriter, r *http.Request) {
+	var req moderateRatingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	rating, err := ratingService.Moderate(r.Context(), pathParam(r, "id"), req.Status)
+	if err != nil {
+		http.Error(w, err.Error(), ratingErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rating)
+}
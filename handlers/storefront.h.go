@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"waheim.api/configs"
+	"waheim.api/services"
+)
+
+var storefrontService = services.NewStorefrontService()
+
+type setCollectionAppsRequest struct {
+	AppIds []string `json:"app_ids"`
+}
+
+func GetStorefrontHandler(w http.ResponseWriter, r *http.Request) {
+	front, err := storefrontService.Storefront(r.Context(), requestLocale(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(front)
+}
+
+func GetCollectionsHandler(w http.ResponseWriter, r *http.Request) {
+	collections, err := storefrontService.GetCollections(r.Context(), requestLocale(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collections)
+}
+
+func CreateCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CollectionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	collection, err := storefrontService.CreateCollection(r.Context(), input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(collection)
+}
+
+func UpdateCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	var input services.CollectionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	collection, err := storefrontService.UpdateCollection(r.Context(), pathParam(r, "id"), input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collection)
+}
+
+func DeleteCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	if err := storefrontService.DeleteCollection(r.Context(), pathParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetCollectionAppsHandler thay danh sách app của collection, thứ tự theo app_ids
+func SetCollectionAppsHandler(w http.ResponseWriter, r *http.Request) {
+	var req setCollectionAppsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	if err := storefrontService.SetCollectionApps(r.Context(), pathParam(r, "id"), req.AppIds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
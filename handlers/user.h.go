@@ -12,10 +12,15 @@ import (
 	"waheim.api/services"
 )
 
-// Adapter cho Gin -> http.Handler, truyền param vào context
+// Adapter cho Gin -> http.Handler, truyền param và client IP vào context
 type paramsKeyType struct{}
 
-var paramsKey = paramsKeyType{}
+type clientIPKeyType struct{}
+
+var (
+	paramsKey   = paramsKeyType{}
+	clientIPKey = clientIPKeyType{}
+)
 
 func GinToHTTPHandler(h http.HandlerFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -24,11 +29,29 @@ func GinToHTTPHandler(h http.HandlerFunc) gin.HandlerFunc {
 			params[p.Key] = p.Value
 		}
 		ctx := context.WithValue(c.Request.Context(), paramsKey, params)
+		ctx = context.WithValue(ctx, clientIPKey, c.ClientIP())
 		h(c.Writer, c.Request.WithContext(ctx))
 	}
 }
 
-var userService = services.NewUserService()
+// clientIP là IP của client theo cấu hình trusted proxy của Gin
+func clientIP(r *http.Request) string {
+	ip, _ := r.Context().Value(clientIPKey).(string)
+	return ip
+}
+
+// pathParam lấy path param do GinToHTTPHandler truyền vào context
+func pathParam(r *http.Request, key string) string {
+	if params, ok := r.Context().Value(paramsKey).(map[string]string); ok {
+		return params[key]
+	}
+	return ""
+}
+
+var (
+	userService = services.NewUserService()
+	logger      = configs.Logger("handlers")
+)
 
 func SignUpHandler(w http.ResponseWriter, r *http.Request) {
 	var req map[string]string
@@ -36,7 +59,7 @@ func SignUpHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
 		return
 	}
-	err := userService.SignUp(req)
+	err := userService.SignUp(r.Context(), req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -50,11 +73,13 @@ func SignInHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
 		return
 	}
-	token, err := userService.SignIn(req)
+	token, err := userService.SignIn(r.Context(), req, clientIP(r), r.UserAgent())
 	if err != nil {
+		configs.SignInTotal.WithLabelValues("failure").Inc()
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
+	configs.SignInTotal.WithLabelValues("success").Inc()
 	secure := r.URL.Scheme == "https" || r.TLS != nil
 	http.SetCookie(w, &http.Cookie{
 		Name:     "token",
@@ -94,7 +119,7 @@ func AuthMeHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_AUTH_HEADER_FORMAT), http.StatusUnauthorized)
 		return
 	}
-	resp, err := userService.AuthMe(token)
+	resp, err := userService.AuthMe(r.Context(), token)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
@@ -137,7 +162,7 @@ func GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	users, err := userService.GetAllUsers(filters, limit, offset)
+	users, err := userService.GetAllUsers(r.Context(), filters, limit, offset)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -168,7 +193,7 @@ func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Permission denied", http.StatusForbidden)
 		return
 	}
-	err := userService.DeleteUser(id)
+	err := userService.DeleteUser(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -190,7 +215,7 @@ func GetUserByIdHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS), http.StatusBadRequest)
 		return
 	}
-	user, err := userService.GetUserById(id)
+	user, err := userService.GetUserById(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -225,7 +250,7 @@ func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
 		return
 	}
-	err := userService.UpdateUser(id, updates)
+	err := userService.UpdateUser(r.Context(), id, updates)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"waheim.api/configs"
+	"waheim.api/services"
+)
+
+var webhookService = services.NewWebhookService()
+
+type createWebhookRequest struct {
+	Url        string   `json:"url"`
+	EventTypes []string `json:"event_types,omitempty"`
+}
+
+type updateWebhookRequest struct {
+	Url        *string  `json:"url,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+	Active     *bool    `json:"active,omitempty"`
+}
+
+func CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	sub, err := webhookService.CreateWebhook(r.Context(), userID, req.Url, req.EventTypes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+func GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value("user_id").(string)
+	subs, err := webhookService.GetWebhooks(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+func UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	var req updateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("user_id").(string)
+	sub, err := webhookService.UpdateWebhook(r.Context(), id, userID, req.Url, req.EventTypes, req.Active)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+func DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	userID, _ := r.Context().Value("user_id").(string)
+	if err := webhookService.DeleteWebhook(r.Context(), id, userID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	userID, _ := r.Context().Value("user_id").(string)
+	deliveries, err := webhookService.GetDeliveries(r.Context(), id, userID, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// TestWebhookHandler gửi ngay một event webhook.test; kết quả nằm trong delivery trả về
+func TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id := pathParam(r, "id")
+	userID, _ := r.Context().Value("user_id").(string)
+	delivery, err := webhookService.SendTest(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
@@ -1,53 +1,200 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"waheim.api/configs"
 	"waheim.api/handlers"
 	"waheim.api/middleware"
+	"waheim.api/services"
 )
 
 func main() {
+	configs.ConfLogger()
+	shutdownTracing := configs.ConfTracing()
+	defer shutdownTracing(context.Background())
 	configs.ConfDb()
-	r := gin.Default()
+	configs.ConfMetrics()
+	configs.ConfJwt()
+	configs.ConfStorage()
+	configs.ConfExport()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var workers sync.WaitGroup
+	runWorker := func(name string, interval time.Duration, job func(context.Context) error) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			services.RunEvery(ctx, name, interval, job)
+		}()
+	}
+	runWorker("account purge", time.Hour, services.NewAccountService().PurgeExpired)
+	runWorker("data export", 10*time.Second, services.NewExportService().ProcessPending)
+	runWorker("outbox relay", 2*time.Second, services.NewOutboxRelay().Run)
+	runWorker("webhook delivery", 5*time.Second, services.NewWebhookService().ProcessPending)
+	builds := services.NewBuildService()
+	runWorker("build timeout", 5*time.Minute, builds.FailStale)
+	runWorker("build mirror", 15*time.Second, builds.MirrorPending)
+	monitor := services.NewMonitorService()
+	runWorker("app health", time.Minute, monitor.ProcessDue)
+	runWorker("app health prune", time.Hour, monitor.Prune)
+	runWorker("app ranking", 15*time.Minute, services.NewRankingService().Refresh)
+	runWorker("recommendations", 10*time.Minute, services.NewRecommendationService().RefreshDue)
+	runWorker("notification prune", time.Hour, services.NewNotificationService().Prune)
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	srv := &http.Server{Addr: ":" + port, Handler: setupRouter()}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server failed", "error", err)
+			stop()
+		}
+	}()
+	slog.Info("server started", "addr", srv.Addr)
+
+	<-ctx.Done()
+	// Cho load balancer thấy /readyz fail trước khi ngừng nhận kết nối
+	services.Health.SetShuttingDown()
+	drain := 5 * time.Second
+	if d, err := time.ParseDuration(os.Getenv("SHUTDOWN_DRAIN")); err == nil {
+		drain = d
+	}
+	slog.Info("shutting down", "drain", drain.String())
+	time.Sleep(drain)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("server shutdown failed", "error", err)
+	}
+	workers.Wait()
+}
+
+// setupRouter đăng ký toàn bộ route và sinh OpenAPI từ chúng; mọi route mới cần metadata trong handlers.RouteDocs
+func setupRouter() *gin.Engine {
+	r := gin.New()
+	// Client IP chỉ lấy từ X-Forwarded-For khi request đi qua proxy trong TRUSTED_PROXIES
+	// (dùng để chống đếm trùng lượt cài ẩn danh), mặc định dùng địa chỉ kết nối
+	var proxies []string
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		proxies = strings.Split(v, ",")
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		slog.Error("invalid TRUSTED_PROXIES", "error", err)
+	}
+	r.Use(otelgin.Middleware(configs.ServiceName), middleware.RequestId(), middleware.RequestLogger(), middleware.Metrics(), gin.Recovery())
+
+	// Manifest và service worker được site của publisher tải từ origin bất kỳ nên
+	// đăng ký trước CORS middleware (Gin gắn middleware vào route lúc đăng ký)
+	r.GET("/app/:id/manifest.webmanifest", handlers.GinToHTTPHandler(handlers.WebManifestHandler))
+	r.GET("/app/:id/sw.js", handlers.GinToHTTPHandler(handlers.ServiceWorkerHandler))
 
 	// CORS config
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"https://thinhphoenix.github.io", "http://localhost:5173"}
 	corsConfig.AllowCredentials = true
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "X-Api-Key", "X-Request-Id"}
+	corsConfig.ExposeHeaders = []string{"X-Request-Id"}
 	r.Use(cors.New(corsConfig))
 
-	r.GET("/ping", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "pong",
-		})
-	})
+	r.GET("/healthz", handlers.GinToHTTPHandler(handlers.HealthzHandler))
+	r.GET("/readyz", handlers.GinToHTTPHandler(handlers.ReadyzHandler))
+	r.GET("/metrics", handlers.GinToHTTPHandler(handlers.MetricsHandler))
+	r.GET("/openapi.json", handlers.GinToHTTPHandler(handlers.OpenAPIHandler))
+	r.GET("/docs", handlers.GinToHTTPHandler(handlers.DocsHandler))
+	r.GET("/.well-known/jwks.json", handlers.GinToHTTPHandler(handlers.JwksHandler))
+	r.POST("/webhooks/github", handlers.GinToHTTPHandler(handlers.GitHubWebhookHandler))
 	auth := r.Group("/auth")
 	auth.POST("/sign-up", func(c *gin.Context) {
 		handlers.SignUpHandler(c.Writer, c.Request)
 	})
-	auth.POST("/sign-in", func(c *gin.Context) {
-		handlers.SignInHandler(c.Writer, c.Request)
-	})
+	auth.POST("/sign-in", handlers.GinToHTTPHandler(handlers.SignInHandler))
 	auth.POST("/sign-out", middleware.RequireAuthorize(), func(c *gin.Context) {
 		handlers.SignOutHandler(c.Writer, c.Request)
 	})
 	auth.GET("/me", middleware.RequireAuthorize(), func(c *gin.Context) {
 		handlers.AuthMeHandler(c.Writer, c.Request)
 	})
+	auth.POST("/api-keys", middleware.RequireAuthorize(), handlers.GinToHTTPHandler(handlers.CreateApiKeyHandler))
+	auth.GET("/api-keys", middleware.RequireAuthorize(), handlers.GinToHTTPHandler(handlers.GetApiKeysHandler))
+	auth.DELETE("/api-keys/:id", middleware.RequireAuthorize(), handlers.GinToHTTPHandler(handlers.RevokeApiKeyHandler))
+	r.GET("/exports/:id/download", handlers.GinToHTTPHandler(handlers.DownloadExportHandler))
 	user := r.Group("/user")
-	user.GET("/:id", middleware.RequireAuthorize("admin"), handlers.GinToHTTPHandler(handlers.GetUserByIdHandler))
-	user.PUT("/:id", middleware.RequireAuthorize("user", "admin"), handlers.GinToHTTPHandler(handlers.UpdateUserHandler))
-	user.DELETE(":id", middleware.RequireAuthorize("user", "admin"), handlers.GinToHTTPHandler(handlers.DeleteUserHandler))
+	user.POST("/me/export", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:read"), handlers.GinToHTTPHandler(handlers.RequestExportHandler))
+	user.GET("/me/export/:id", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:read"), handlers.GinToHTTPHandler(handlers.GetExportHandler))
+	user.GET("/me/favorites", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:read"), handlers.GinToHTTPHandler(handlers.GetFavoritesHandler))
+	user.PUT("/me/favorites/:appId", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.AddFavoriteHandler))
+	user.DELETE("/me/favorites/:appId", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.RemoveFavoriteHandler))
+	user.GET("/me/notifications", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:read"), handlers.GinToHTTPHandler(handlers.GetNotificationsHandler))
+	user.POST("/me/notifications/read-all", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.MarkAllNotificationsReadHandler))
+	user.POST("/me/notifications/:notificationId/read", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.MarkNotificationReadHandler))
+	user.GET("/me/notification-preferences", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:read"), handlers.GinToHTTPHandler(handlers.GetNotificationPreferencesHandler))
+	user.PUT("/me/notification-preferences", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.SetNotificationPreferencesHandler))
+	user.GET("/me/recommendations", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:read"), handlers.GinToHTTPHandler(handlers.GetRecommendationsHandler))
+	user.GET("/:id", middleware.RequireAuthorize("admin"), middleware.RequireScope("user:read"), handlers.GinToHTTPHandler(handlers.GetUserByIdHandler))
+	user.PUT("/:id", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.UpdateUserHandler))
+	user.DELETE(":id", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.DeleteUserHandler))
+	user.POST("/:id/deactivate", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.DeactivateUserHandler))
+	user.POST("/:id/activate", middleware.RequireAuthorize("admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.ActivateUserHandler))
+	user.POST("/:id/restore", middleware.RequireAuthorize("admin"), middleware.RequireScope("user:write"), handlers.GinToHTTPHandler(handlers.RestoreUserHandler))
 	app := r.Group("/app")
-	app.GET("/:id", handlers.GinToHTTPHandler(handlers.GetAppByIdHandler))
+	app.GET("/:id", middleware.OptionalAuthorize(), handlers.GinToHTTPHandler(handlers.GetAppByIdHandler))
 	app.GET("", handlers.GinToHTTPHandler(handlers.GetAllAppsHandler))
-	app.POST("", middleware.RequireAuthorize("user", "admin"), handlers.GinToHTTPHandler(handlers.CreateAppHandler))
-	app.PUT("/:id", middleware.RequireAuthorize("user", "admin"), handlers.GinToHTTPHandler(handlers.UpdateAppHandler))
-	app.DELETE("/:id", middleware.RequireAuthorize("user", "admin"), handlers.GinToHTTPHandler(handlers.DeleteAppHandler))
-	r.Run()
+	app.POST("", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.CreateAppHandler))
+	app.PUT("/:id", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.UpdateAppHandler))
+	app.DELETE("/:id", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.DeleteAppHandler))
+	app.GET("/:id/download/android", middleware.OptionalAuthorize(), handlers.GinToHTTPHandler(handlers.DownloadAndroidHandler))
+	app.POST("/:id/installs", middleware.OptionalAuthorize(), handlers.GinToHTTPHandler(handlers.RecordWebInstallHandler))
+	app.POST("/:id/android", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.UploadApkHandler))
+	app.GET("/:id/similar", handlers.GinToHTTPHandler(handlers.GetSimilarAppsHandler))
+	app.GET("/:id/ratings", handlers.GinToHTTPHandler(handlers.GetAppRatingsHandler))
+	app.POST("/:id/ratings", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.PostRatingHandler))
+	app.PUT("/:id/ratings/:ratingId/helpful", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.AddHelpfulVoteHandler))
+	app.DELETE("/:id/ratings/:ratingId/helpful", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.RemoveHelpfulVoteHandler))
+	app.POST("/:id/ratings/:ratingId/reports", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.ReportRatingHandler))
+	app.PUT("/:id/ratings/:ratingId/reply", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.ReplyRatingHandler))
+	app.DELETE("/:id/ratings/:ratingId/reply", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"), handlers.GinToHTTPHandler(handlers.DeleteRatingReplyHandler))
+	r.GET("/categories", handlers.GinToHTTPHandler(handlers.GetCategoriesHandler))
+	r.GET("/tags", handlers.GinToHTTPHandler(handlers.GetTagsHandler))
+	r.GET("/storefront", handlers.GinToHTTPHandler(handlers.GetStorefrontHandler))
+	admin := r.Group("/admin", middleware.RequireAuthorize("admin"), middleware.RequireScope("app:write"))
+	admin.POST("/categories", handlers.GinToHTTPHandler(handlers.CreateCategoryHandler))
+	admin.PUT("/categories/:id", handlers.GinToHTTPHandler(handlers.UpdateCategoryHandler))
+	admin.DELETE("/categories/:id", handlers.GinToHTTPHandler(handlers.DeleteCategoryHandler))
+	admin.POST("/tags", handlers.GinToHTTPHandler(handlers.CreateTagHandler))
+	admin.PUT("/tags/:id", handlers.GinToHTTPHandler(handlers.UpdateTagHandler))
+	admin.DELETE("/tags/:id", handlers.GinToHTTPHandler(handlers.DeleteTagHandler))
+	admin.GET("/collections", handlers.GinToHTTPHandler(handlers.GetCollectionsHandler))
+	admin.POST("/collections", handlers.GinToHTTPHandler(handlers.CreateCollectionHandler))
+	admin.PUT("/collections/:id", handlers.GinToHTTPHandler(handlers.UpdateCollectionHandler))
+	admin.DELETE("/collections/:id", handlers.GinToHTTPHandler(handlers.DeleteCollectionHandler))
+	admin.PUT("/collections/:id/apps", handlers.GinToHTTPHandler(handlers.SetCollectionAppsHandler))
+	admin.GET("/ratings", handlers.GinToHTTPHandler(handlers.GetRatingModerationQueueHandler))
+	admin.PUT("/ratings/:id/status", handlers.GinToHTTPHandler(handlers.ModerateRatingHandler))
+	publisher := r.Group("/publisher", middleware.RequireAuthorize("user", "admin"), middleware.RequireScope("app:write"))
+	publisher.POST("/webhooks", handlers.GinToHTTPHandler(handlers.CreateWebhookHandler))
+	publisher.GET("/webhooks", handlers.GinToHTTPHandler(handlers.GetWebhooksHandler))
+	publisher.PUT("/webhooks/:id", handlers.GinToHTTPHandler(handlers.UpdateWebhookHandler))
+	publisher.DELETE("/webhooks/:id", handlers.GinToHTTPHandler(handlers.DeleteWebhookHandler))
+	publisher.GET("/webhooks/:id/deliveries", handlers.GinToHTTPHandler(handlers.GetWebhookDeliveriesHandler))
+	publisher.POST("/webhooks/:id/test", handlers.GinToHTTPHandler(handlers.TestWebhookHandler))
+	handlers.SetOpenAPIRoutes(r.Routes())
+	return r
 }
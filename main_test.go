@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"waheim.api/configs"
+	"waheim.api/handlers"
+)
+
+func TestOpenAPICoversRegisteredRoutes(t *testing.T) {
+	routes := setupRouter().Routes()
+	spec := handlers.OpenAPISpec()
+	paths, _ := spec["paths"].(map[string]map[string]interface{})
+	documented := map[string]bool{}
+	for _, doc := range handlers.RouteDocs {
+		documented[doc.Method+" "+doc.Path] = true
+	}
+
+	registered := map[string]bool{}
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		path := configs.OpenAPIPath(route.Path)
+		if _, ok := paths[path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("route %s is registered but missing from the OpenAPI spec", key)
+		}
+		// Metadata tra theo đúng method và path Gin, nên sai method hay tên param cũng bị bắt
+		if !documented[key] {
+			t.Errorf("route %s has no metadata in handlers.RouteDocs", key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			t.Errorf("metadata for %s does not match any registered route", key)
+		}
+	}
+	if len(paths) == 0 {
+		t.Fatal("spec has no paths")
+	}
+}
+
+func TestOpenAPIPathParamsComeFromRouter(t *testing.T) {
+	setupRouter()
+	op, _ := handlers.OpenAPISpec()["paths"].(map[string]map[string]interface{})["/app/{id}/ratings/{ratingId}/reply"]["put"].(map[string]interface{})
+	params, _ := op["parameters"].([]map[string]interface{})
+	names := []string{}
+	for _, p := range params {
+		if p["in"] == "path" {
+			names = append(names, p["name"].(string))
+		}
+	}
+	if strings.Join(names, ",") != "id,ratingId" {
+		t.Errorf("path params = %v", names)
+	}
+	info, _ := handlers.OpenAPISpec()["info"].(map[string]string)
+	if info["description"] == "" {
+		t.Error("spec does not describe which parts are hand-written")
+	}
+}
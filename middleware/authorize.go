@@ -6,8 +6,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"waheim.api/configs"
+	"waheim.api/services"
 )
 
+var apiKeyService = services.NewApiKeyService()
+var accountService = services.NewAccountService()
+
+// RequireAuthorize chấp nhận Bearer JWT (header hoặc cookie) hoặc API key
+// (header X-Api-Key hoặc Bearer whk_...). Với API key, role lấy từ user sở hữu.
 func RequireAuthorize(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.GetHeader("Authorization") == "" {
@@ -23,20 +29,49 @@ func RequireAuthorize(roles ...string) gin.HandlerFunc {
 				token = "Bearer " + cookie.Value
 			}
 		}
-		if !strings.HasPrefix(token, "Bearer ") {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Missing or invalid token"})
-			return
+		apiKey := c.GetHeader("X-Api-Key")
+		if apiKey == "" && strings.HasPrefix(token, "Bearer "+services.ApiKeyPrefix) {
+			apiKey = strings.TrimPrefix(token, "Bearer ")
 		}
-		token = strings.TrimPrefix(token, "Bearer ")
-		claims, err := configs.ValidateJwt(token)
-		if err != nil {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token"})
-			return
+
+		var userId, role, authMethod string
+		var scopes []string
+		if apiKey != "" {
+			key, err := apiKeyService.Authenticate(c.Request.Context(), apiKey)
+			if err != nil {
+				c.AbortWithStatusJSON(401, gin.H{"error": "Invalid API key"})
+				return
+			}
+			userId, role, authMethod, scopes = key.UserId, key.Role, "api_key", key.Scopes
+		} else {
+			if !strings.HasPrefix(token, "Bearer ") {
+				c.AbortWithStatusJSON(401, gin.H{"error": "Missing or invalid token"})
+				return
+			}
+			token = strings.TrimPrefix(token, "Bearer ")
+			claims, err := configs.ValidateJwt(token)
+			if err != nil {
+				c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token"})
+				return
+			}
+			userId, _ = claims["sub"].(string)
+			role, _ = claims["role"].(string)
+			authMethod = "jwt"
+			// JWT không thu hồi được nên kiểm tra tài khoản còn active mỗi request
+			active, err := accountService.IsActive(c.Request.Context(), userId)
+			if err != nil {
+				c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(401, gin.H{"error": "Account is not active"})
+				return
+			}
 		}
-		userId, _ := claims["user_id"].(string)
-		role, _ := claims["role"].(string)
 		ctx := context.WithValue(c.Request.Context(), "user_id", userId)
 		ctx = context.WithValue(ctx, "role", role)
+		ctx = context.WithValue(ctx, "auth_method", authMethod)
+		ctx = context.WithValue(ctx, "scopes", scopes)
 		c.Request = c.Request.WithContext(ctx)
 		// Nếu truyền roles, kiểm tra quyền
 		if len(roles) > 0 {
@@ -55,3 +90,44 @@ func RequireAuthorize(roles ...string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalAuthorize cho route công khai: có thông tin đăng nhập thì xác thực như
+// RequireAuthorize để handler biết user, không có thì cho qua với user ẩn danh
+func OptionalAuthorize() gin.HandlerFunc {
+	authorize := RequireAuthorize()
+	return func(c *gin.Context) {
+		_, err := c.Request.Cookie("token")
+		if c.GetHeader("Authorization") == "" && c.GetHeader("X-Api-Key") == "" && err != nil {
+			c.Next()
+			return
+		}
+		authorize(c)
+	}
+}
+
+// RequireScope giới hạn request dùng API key theo scope; JWT của user luôn được qua.
+// Phải đặt sau RequireAuthorize.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authMethod, _ := c.Request.Context().Value("auth_method").(string)
+		if authMethod != "api_key" {
+			c.Next()
+			return
+		}
+		granted, _ := c.Request.Context().Value("scopes").([]string)
+		for _, s := range scopes {
+			found := false
+			for _, g := range granted {
+				if g == s {
+					found = true
+					break
+				}
+			}
+			if !found {
+				c.AbortWithStatusJSON(403, gin.H{"error": "Insufficient API key scope"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
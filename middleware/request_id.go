@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"waheim.api/configs"
+)
+
+var (
+	validRequestId = regexp.MustCompile(`^[A-Za-z0-9\-_.]{8,64}$`)
+	logger         = configs.Logger("http")
+)
+
+// RequestId gắn request id (lấy từ X-Request-Id nếu hợp lệ) vào context và response header
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-Id")
+		if !validRequestId.MatchString(id) {
+			b := make([]byte, 12)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Header("X-Request-Id", id)
+		c.Request = c.Request.WithContext(configs.WithRequestId(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// RequestLogger ghi access log dạng structured thay cho gin.Logger
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		logger.InfoContext(c.Request.Context(), "request",
+			"method", c.Request.Method,
+			"route", route,
+			"status", c.Writer.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
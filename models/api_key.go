@@ -0,0 +1,30 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type ApiKey struct {
+	Id         string         `db:"id" json:"id"`
+	UserId     string         `db:"user_id" json:"user_id"`
+	Name       string         `db:"name" json:"name"`
+	Prefix     string         `db:"prefix" json:"prefix"`
+	SecretHash string         `db:"secret_hash" json:"-"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes"`
+	ExpiresAt  sql.NullTime   `db:"expires_at" json:"expires_at"`
+	LastUsedAt sql.NullTime   `db:"last_used_at" json:"last_used_at"`
+	CreatedAt  string         `db:"created_at" json:"created_at"`
+	RevokedAt  sql.NullTime   `db:"revoked_at" json:"revoked_at"`
+	// Chỉ có giá trị ngay sau khi tạo, không lưu vào DB
+	Key string `db:"-" json:"key,omitempty"`
+}
+
+// ApiKeyOwner là key kèm trạng thái của user sở hữu, dùng khi xác thực request
+type ApiKeyOwner struct {
+	ApiKey
+	Role          string         `db:"role"`
+	IsActive      bool           `db:"is_active"`
+	UserDeletedAt sql.NullString `db:"user_deleted_at"`
+}
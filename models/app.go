@@ -2,10 +2,18 @@ package models
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 
 	"github.com/lib/pq"
 )
 
+const (
+	AppStatusDraft     = "draft"
+	AppStatusPublished = "published"
+)
+
 type App struct {
 	Id                string         `db:"id" json:"id"`
 	Name              string         `db:"name" json:"name"`
@@ -24,4 +32,54 @@ type App struct {
 	Downloads         int            `db:"downloads" json:"downloads"`
 	AndroidInstallUri string         `db:"android_install_uri" json:"android_install_uri"`
 	IOSInstallUri     string         `db:"ios_install_uri" json:"ios_install_uri"`
+	ThemeColor        string         `db:"theme_color" json:"theme_color"`
+	BackgroundColor   string         `db:"background_color" json:"background_color"`
+	Icons             AppIcons       `db:"icons" json:"icons"`
+	HealthStatus      string         `db:"health_status" json:"health_status"`
+	HealthFailures    int            `db:"health_failures" json:"-"`
+	HealthCheckedAt   sql.NullTime   `db:"health_checked_at" json:"-"`
+	RatingCount       int            `db:"rating_count" json:"rating_count"`
+	// RatingScore là trung bình Bayes của rating, TrendingScore là điểm hoạt động gần đây
+	// (xem RankingService); cả hai được job ranking tính lại định kỳ
+	RatingScore   float64      `db:"rating_score" json:"rating_score"`
+	TrendingScore float64      `db:"trending_score" json:"trending_score"`
+	RankedAt      sql.NullTime `db:"ranked_at" json:"-"`
+	FavoriteCount int          `db:"favorite_count" json:"favorite_count"`
+	// Android là version APK mới nhất, chỉ có ở API chi tiết app
+	Android *AppVersion `db:"-" json:"android,omitempty"`
+	// Uptime tổng hợp từ app_health_checks, chỉ có ở API chi tiết app
+	Uptime *AppUptime `db:"-" json:"uptime,omitempty"`
+	// IsFavorite chỉ có ở API chi tiết app khi request đã đăng nhập
+	IsFavorite *bool `db:"-" json:"is_favorite,omitempty"`
+}
+
+// AppIcon là một biến thể icon theo định dạng icons của Web App Manifest
+type AppIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// AppIcons được lưu dạng JSONB
+type AppIcons []AppIcon
+
+func (a AppIcons) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *AppIcons) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	}
+	return errors.New("unsupported type for AppIcons")
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	AppHealthUnknown   = "unknown"
+	AppHealthHealthy   = "healthy"
+	AppHealthUnhealthy = "unhealthy"
+)
+
+// AppHealthCheck là kết quả một lần probe Uri của app
+type AppHealthCheck struct {
+	Id         string         `db:"id" json:"id"`
+	AppId      string         `db:"app_id" json:"app_id"`
+	Ok         bool           `db:"ok" json:"ok"`
+	StatusCode int            `db:"status_code" json:"status_code"`
+	ResponseMs int64          `db:"response_ms" json:"response_ms"`
+	Error      sql.NullString `db:"error" json:"error"`
+	CheckedAt  time.Time      `db:"checked_at" json:"checked_at"`
+}
+
+// AppUptime là tổng hợp lịch sử probe, trả kèm API chi tiết app
+type AppUptime struct {
+	Status        string       `json:"status"`
+	LastCheckedAt sql.NullTime `json:"last_checked_at"`
+	Uptime24h     *float64     `db:"uptime_24h" json:"uptime_24h"`
+	Uptime7d      *float64     `db:"uptime_7d" json:"uptime_7d"`
+	Uptime30d     *float64     `db:"uptime_30d" json:"uptime_30d"`
+	AvgResponseMs *float64     `db:"avg_response_ms" json:"avg_response_ms"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	InstallSourceAndroid = "android"
+	InstallSourceWeb     = "web"
+)
+
+// AppInstall là một lượt cài app: tải APK hoặc client báo đã cài web app.
+// Installer là khoá chống đếm trùng: user id, hoặc hash của IP với người dùng ẩn danh.
+type AppInstall struct {
+	Id        string         `db:"id" json:"id"`
+	AppId     string         `db:"app_id" json:"app_id"`
+	UserId    sql.NullString `db:"user_id" json:"user_id"`
+	Installer string         `db:"installer" json:"-"`
+	Source    string         `db:"source" json:"source"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	Recorded  bool           `db:"-" json:"recorded"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	AppVersionSourceBuild  = "build"
+	AppVersionSourceUpload = "upload"
+)
+
+// ApkInfo là metadata đọc từ AndroidManifest.xml và chữ ký của APK
+type ApkInfo struct {
+	PackageName string         `db:"package_name" json:"package_name"`
+	VersionCode int64          `db:"version_code" json:"version_code"`
+	VersionName string         `db:"version_name" json:"version_name"`
+	MinSdk      int            `db:"min_sdk" json:"min_sdk"`
+	TargetSdk   int            `db:"target_sdk" json:"target_sdk"`
+	Permissions pq.StringArray `db:"permissions" json:"permissions"`
+	CertSha256  string         `db:"cert_sha256" json:"cert_sha256"`
+}
+
+// AppVersion là một APK đã lưu trong storage, từ build hoặc do publisher upload
+type AppVersion struct {
+	Id        string         `db:"id" json:"id"`
+	AppId     string         `db:"app_id" json:"app_id"`
+	BuildId   sql.NullString `db:"build_id" json:"build_id"`
+	Source    string         `db:"source" json:"source"`
+	ApkKey    string         `db:"apk_key" json:"-"`
+	ApkSha256 string         `db:"apk_sha256" json:"apk_sha256"`
+	ApkSize   int64          `db:"apk_size" json:"apk_size"`
+	ApkInfo
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
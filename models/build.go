@@ -0,0 +1,31 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	BuildStatusQueued    = "queued"
+	BuildStatusSucceeded = "succeeded"
+	BuildStatusFailed    = "failed"
+)
+
+// Build là một lần build APK qua GitHub Actions (repository_dispatch)
+type Build struct {
+	Id            string         `db:"id" json:"id"`
+	AppId         string         `db:"app_id" json:"app_id"`
+	Status        string         `db:"status" json:"status"`
+	WorkflowRunId sql.NullInt64  `db:"workflow_run_id" json:"workflow_run_id"`
+	ArtifactUrl   sql.NullString `db:"artifact_url" json:"-"`
+	VersionId     sql.NullString `db:"version_id" json:"version_id"`
+	LogsUrl       sql.NullString `db:"logs_url" json:"logs_url"`
+	Error         sql.NullString `db:"error" json:"error"`
+	// ArtifactsUrl là artifacts_url của run thành công, chờ worker mirror APK về storage
+	ArtifactsUrl   sql.NullString `db:"artifacts_url" json:"-"`
+	MirrorAttempts int            `db:"mirror_attempts" json:"mirror_attempts"`
+	NextMirrorAt   sql.NullTime   `db:"next_mirror_at" json:"-"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
+	CompletedAt    sql.NullTime   `db:"completed_at" json:"completed_at"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// LocalizedText là map locale -> chuỗi, lưu dạng JSONB
+type LocalizedText map[string]string
+
+// Get trả về bản dịch theo locale, không có thì dùng "en" rồi tới locale bất kỳ
+func (t LocalizedText) Get(locale string) string {
+	if v, ok := t[locale]; ok && v != "" {
+		return v
+	}
+	if v, ok := t["en"]; ok && v != "" {
+		return v
+	}
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if t[k] != "" {
+			return t[k]
+		}
+	}
+	return ""
+}
+
+func (t LocalizedText) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(t)
+}
+
+func (t *LocalizedText) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	}
+	return errors.New("unsupported type for LocalizedText")
+}
+
+// Category là danh mục app; apps.category lưu slug của category.
+// Chỉ có hai cấp: category gốc và subcategory (parent_id là category gốc).
+type Category struct {
+	Id       string         `db:"id" json:"id"`
+	Slug     string         `db:"slug" json:"slug"`
+	Names    LocalizedText  `db:"names" json:"names"`
+	Name     string         `db:"-" json:"name"`
+	Icon     string         `db:"icon" json:"icon"`
+	Position int            `db:"position" json:"position"`
+	ParentId sql.NullString `db:"parent_id" json:"parent_id"`
+	// AppCount là số app published thuộc category (gồm cả subcategory)
+	AppCount  int       `db:"app_count" json:"app_count"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Tag là một từ trong bộ tag chuẩn; apps.tags lưu slug của tag
+type Tag struct {
+	Id   string `db:"id" json:"id"`
+	Slug string `db:"slug" json:"slug"`
+	Name string `db:"name" json:"name"`
+	// UsageCount là số app đang gắn tag
+	UsageCount int       `db:"usage_count" json:"usage_count"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
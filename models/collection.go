@@ -0,0 +1,28 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	CollectionKindCurated = "curated"
+	CollectionKindAuto    = "auto"
+)
+
+// Collection là nhóm app hiển thị trên trang chủ store. Collection curated do admin
+// chọn app và lịch hiển thị (starts_at/ends_at), collection auto được sinh khi đọc.
+type Collection struct {
+	Id        string        `db:"id" json:"id,omitempty"`
+	Slug      string        `db:"slug" json:"slug"`
+	Titles    LocalizedText `db:"titles" json:"titles"`
+	Title     string        `db:"-" json:"title"`
+	Kind      string        `db:"-" json:"kind"`
+	BannerUrl string        `db:"banner_url" json:"banner_url"`
+	Position  int           `db:"position" json:"position"`
+	StartsAt  sql.NullTime  `db:"starts_at" json:"starts_at"`
+	EndsAt    sql.NullTime  `db:"ends_at" json:"ends_at"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `db:"updated_at" json:"updated_at"`
+	Apps      []App         `db:"-" json:"apps"`
+}
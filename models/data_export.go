@@ -0,0 +1,18 @@
+package models
+
+import "database/sql"
+
+type DataExport struct {
+	Id           string         `db:"id" json:"id"`
+	UserId       string         `db:"user_id" json:"user_id"`
+	Status       string         `db:"status" json:"status"`
+	StorageKey   sql.NullString `db:"storage_key" json:"-"`
+	Size         sql.NullInt64  `db:"size" json:"size"`
+	Error        sql.NullString `db:"error" json:"error"`
+	CreatedAt    string         `db:"created_at" json:"created_at"`
+	CompletedAt  sql.NullTime   `db:"completed_at" json:"completed_at"`
+	ExpiresAt    sql.NullTime   `db:"expires_at" json:"expires_at"`
+	DownloadedAt sql.NullTime   `db:"downloaded_at" json:"downloaded_at"`
+	StartedAt    sql.NullTime   `db:"started_at" json:"started_at"`
+	DownloadUrl  string         `db:"-" json:"download_url,omitempty"`
+}
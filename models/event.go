@@ -0,0 +1,65 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Các loại domain event được ghi vào outbox
+const (
+	EventAppCreated     = "app.created"
+	EventAppPublished   = "app.published"
+	EventAppDeleted     = "app.deleted"
+	EventRatingPosted   = "rating.posted"
+	EventUserDeleted    = "user.deleted"
+	EventBuildCompleted = "build.completed"
+	EventBuildFailed    = "build.failed"
+	EventWebhookTest    = "webhook.test"
+	EventAppUnhealthy   = "app.unhealthy"
+	EventAppRecovered   = "app.recovered"
+	EventRatingReplied  = "rating.replied"
+)
+
+type Event struct {
+	Id            string         `db:"id" json:"id"`
+	Type          string         `db:"event_type" json:"type"`
+	AggregateType string         `db:"aggregate_type" json:"aggregate_type"`
+	AggregateId   string         `db:"aggregate_id" json:"aggregate_id"`
+	Payload       types.JSONText `db:"payload" json:"payload"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+	Attempts      int            `db:"attempts" json:"-"`
+	NextAttemptAt time.Time      `db:"next_attempt_at" json:"-"`
+	LastError     sql.NullString `db:"last_error" json:"-"`
+	DeliveredAt   sql.NullTime   `db:"delivered_at" json:"-"`
+}
+
+// BuildEvent là payload của build.completed / build.failed
+type BuildEvent struct {
+	BuildId           string `json:"build_id"`
+	AppId             string `json:"app_id"`
+	PublisherId       string `json:"publisher_id"`
+	AndroidInstallUri string `json:"android_install_uri,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+	LogsUrl           string `json:"logs_url,omitempty"`
+}
+
+// AppHealthEvent là payload của app.unhealthy / app.recovered
+type AppHealthEvent struct {
+	AppId       string `json:"app_id"`
+	PublisherId string `json:"publisher_id"`
+	Uri         string `json:"uri"`
+	Failures    int    `json:"consecutive_failures"`
+	Error       string `json:"error,omitempty"`
+}
+
+// RatingReplyEvent là payload của rating.replied, gửi tới người viết đánh giá
+type RatingReplyEvent struct {
+	RatingId    string `json:"rating_id"`
+	AppId       string `json:"app_id"`
+	ReviewerId  string `json:"reviewer_id"`
+	PublisherId string `json:"publisher_id"`
+	Reply       string `json:"reply"`
+	Edited      bool   `json:"edited"`
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type Favorite struct {
+	UserId    string    `db:"user_id" json:"user_id"`
+	AppId     string    `db:"app_id" json:"app_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Các loại thông báo, user bật/tắt theo loại
+const (
+	NotificationBuild       = "build"
+	NotificationAppStatus   = "app_status"
+	NotificationAppHealth   = "app_health"
+	NotificationReviewReply = "review_reply"
+	// Cảnh báo bảo mật luôn được gửi, không tắt được
+	NotificationSecurity = "security"
+)
+
+var NotificationTypes = []string{
+	NotificationBuild,
+	NotificationAppStatus,
+	NotificationAppHealth,
+	NotificationReviewReply,
+	NotificationSecurity,
+}
+
+type Notification struct {
+	Id        string         `db:"id" json:"id"`
+	UserId    string         `db:"user_id" json:"-"`
+	Type      string         `db:"type" json:"type"`
+	Title     string         `db:"title" json:"title"`
+	Body      string         `db:"body" json:"body"`
+	Data      types.JSONText `db:"data" json:"data"`
+	EventId   sql.NullString `db:"event_id" json:"-"`
+	ReadAt    sql.NullTime   `db:"read_at" json:"read_at"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
@@ -1,13 +1,68 @@
 package models
 
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	RatingStatusPublished = "published"
+	// Chờ kiểm duyệt: bị bộ lọc nội dung giữ lại hoặc bị ẩn tự động do nhiều báo cáo
+	RatingStatusPending = "pending"
+	// Admin đã ẩn sau khi kiểm duyệt
+	RatingStatusHidden = "hidden"
+)
+
+const (
+	RatingReportOpen     = "open"
+	RatingReportResolved = "resolved"
+)
+
+// RatingReportReasons là các lý do báo cáo đánh giá được chấp nhận
+var RatingReportReasons = []string{"spam", "offensive", "off_topic", "fake", "other"}
+
 type Rating struct {
-	Id        string `db:"id" json:"id"`
-	UserId    string `db:"user_id" json:"user_id"`
-	AppId     string `db:"app_id" json:"app_id"`
-	Comment   string `db:"comment" json:"comment"`
-	Stars     int    `db:"stars" json:"stars"`
-	CreatedAt string `db:"created_at" json:"created_at"`
-	UpdatedAt string `db:"updated_at" json:"updated_at"`
-	DeletedAt string `db:"deleted_at" json:"deleted_at"`
-	Status    string `db:"status" json:"status"`
+	Id           string         `db:"id" json:"id"`
+	UserId       string         `db:"user_id" json:"user_id"`
+	AppId        string         `db:"app_id" json:"app_id"`
+	Comment      sql.NullString `db:"comment" json:"comment"`
+	Stars        int            `db:"stars" json:"stars"`
+	CreatedAt    string         `db:"created_at" json:"created_at"`
+	UpdatedAt    string         `db:"updated_at" json:"updated_at"`
+	DeletedAt    sql.NullString `db:"deleted_at" json:"deleted_at"`
+	Status       sql.NullString `db:"status" json:"status"`
+	HelpfulCount int            `db:"helpful_count" json:"helpful_count"`
+	ReportCount  int            `db:"report_count" json:"-"`
+	PostedAt     sql.NullTime   `db:"posted_at" json:"-"`
+	Reply        *RatingReply   `db:"-" json:"reply,omitempty"`
+}
+
+// RatingReply là phản hồi của publisher cho một đánh giá về app của họ
+type RatingReply struct {
+	RatingId    string    `db:"rating_id" json:"rating_id"`
+	PublisherId string    `db:"publisher_id" json:"publisher_id"`
+	Body        string    `db:"body" json:"body"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// RatingReport là một báo cáo vi phạm của user cho đánh giá
+type RatingReport struct {
+	Id         string         `db:"id" json:"id"`
+	RatingId   string         `db:"rating_id" json:"rating_id"`
+	UserId     sql.NullString `db:"user_id" json:"-"`
+	Reason     string         `db:"reason" json:"reason"`
+	Details    sql.NullString `db:"details" json:"details"`
+	Status     string         `db:"status" json:"status"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	ResolvedAt sql.NullTime   `db:"resolved_at" json:"resolved_at"`
+}
+
+// RatingModeration là một đánh giá trong hàng đợi kiểm duyệt kèm lý do của các báo cáo chưa xử lý
+type RatingModeration struct {
+	Rating
+	OpenReports int            `db:"open_reports" json:"open_reports"`
+	Reasons     pq.StringArray `db:"reasons" json:"reasons"`
 }
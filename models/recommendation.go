@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UserRecommendations là danh sách app gợi ý đã tính sẵn cho user, xếp theo điểm giảm dần
+type UserRecommendations struct {
+	UserId     string         `db:"user_id" json:"user_id"`
+	AppIds     pq.StringArray `db:"app_ids" json:"app_ids"`
+	ComputedAt time.Time      `db:"computed_at" json:"computed_at"`
+}
@@ -20,4 +20,17 @@ type User struct {
 	DateOfBirth sql.NullTime   `db:"date_of_birth" json:"date_of_birth"`
 	Gender      sql.NullString `db:"gender" json:"gender"`
 	Status      sql.NullString `db:"status" json:"status"`
+	PurgedAt    sql.NullTime   `db:"purged_at" json:"purged_at"`
+}
+
+// Giá trị users.status cho tài khoản bị tắt: user tự tắt thì đăng nhập lại sẽ mở lại,
+// admin tắt thì chỉ admin mở lại được
+const (
+	UserStatusDeactivated = "deactivated"
+	UserStatusSuspended   = "suspended"
+)
+
+// SelfDeactivated cho biết tài khoản do chính user tắt
+func (u User) SelfDeactivated() bool {
+	return !u.IsActive && u.Status.Valid && u.Status.String == UserStatusDeactivated
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// UserSession là một lần đăng nhập bằng mật khẩu (JWT được cấp)
+type UserSession struct {
+	Id        string         `db:"id" json:"id"`
+	UserId    string         `db:"user_id" json:"user_id"`
+	Ip        sql.NullString `db:"ip" json:"ip"`
+	UserAgent sql.NullString `db:"user_agent" json:"user_agent"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	ExpiresAt time.Time      `db:"expires_at" json:"expires_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type WebhookSubscription struct {
+	Id          string         `db:"id" json:"id"`
+	PublisherId string         `db:"publisher_id" json:"publisher_id"`
+	Url         string         `db:"url" json:"url"`
+	Secret      string         `db:"secret" json:"secret,omitempty"`
+	EventTypes  pq.StringArray `db:"event_types" json:"event_types"`
+	Active      bool           `db:"active" json:"active"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+	DeletedAt   sql.NullTime   `db:"deleted_at" json:"-"`
+}
+
+type WebhookDelivery struct {
+	Id             string         `db:"id" json:"id"`
+	SubscriptionId string         `db:"subscription_id" json:"subscription_id"`
+	EventId        string         `db:"event_id" json:"event_id"`
+	EventType      string         `db:"event_type" json:"event_type"`
+	Payload        types.JSONText `db:"payload" json:"payload"`
+	Status         string         `db:"status" json:"status"`
+	Attempts       int            `db:"attempts" json:"attempts"`
+	ResponseCode   sql.NullInt64  `db:"response_code" json:"response_code"`
+	ResponseBody   sql.NullString `db:"response_body" json:"response_body"`
+	Error          sql.NullString `db:"error" json:"error"`
+	DurationMs     sql.NullInt64  `db:"duration_ms" json:"duration_ms"`
+	NextAttemptAt  time.Time      `db:"next_attempt_at" json:"next_attempt_at"`
+	DeliveredAt    sql.NullTime   `db:"delivered_at" json:"delivered_at"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+func CreateApiKey(ctx context.Context, key *models.ApiKey) error {
+	db := configs.DB
+	query := `INSERT INTO api_keys (user_id, name, prefix, secret_hash, scopes, expires_at, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,NOW())
+		RETURNING id, created_at`
+	err := db.QueryRowxContext(ctx, query,
+		key.UserId,
+		key.Name,
+		key.Prefix,
+		key.SecretHash,
+		key.Scopes,
+		key.ExpiresAt,
+	).Scan(&key.Id, &key.CreatedAt)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "create api key", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func GetApiKeysByUser(ctx context.Context, userId string) ([]models.ApiKey, error) {
+	db := configs.DB
+	keys := []models.ApiKey{}
+	err := db.SelectContext(ctx, &keys, "SELECT * FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at DESC", userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get api keys", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return keys, nil
+}
+
+func GetApiKeyOwnerByPrefix(ctx context.Context, prefix string) (models.ApiKeyOwner, error) {
+	db := configs.DB
+	var key models.ApiKeyOwner
+	query := `SELECT k.*, u.role, u.is_active, u.deleted_at AS user_deleted_at
+		FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE k.prefix = $1 AND k.revoked_at IS NULL`
+	err := db.GetContext(ctx, &key, query, prefix)
+	if err != nil {
+		return key, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_API_KEY))
+	}
+	return key, nil
+}
+
+func TouchApiKey(ctx context.Context, id string) error {
+	db := configs.DB
+	_, err := db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "touch api key", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func RevokeApiKey(ctx context.Context, id, userId string) error {
+	db := configs.DB
+	query := "UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL"
+	res, err := db.ExecContext(ctx, query, id, userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "revoke api key", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return errors.New(configs.GetErrString(configs.ErrorCode_API_KEY_NOT_FOUND))
+	}
+	return nil
+}
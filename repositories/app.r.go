@@ -1,66 +1,97 @@
 package repositories
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"waheim.api/configs"
 	"waheim.api/models"
 )
 
-func CreateApp(app *models.App) error {
-	db := configs.DB
-	query := `INSERT INTO apps (name, description, status, uri, icon, publisher_id, screenshots, category, tags, rating, downloads, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,NOW(),NOW())
+func CreateApp(ctx context.Context, app *models.App) error {
+	query := `INSERT INTO apps (name, description, status, uri, icon, publisher_id, screenshots, category, tags, rating, downloads,
+			theme_color, background_color, icons, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,NOW(),NOW())
 		RETURNING id, created_at, updated_at, deleted_at`
-	return db.QueryRowx(query,
-		app.Name,
-		app.Description,
-		app.Status,
-		app.Uri,
-		app.Icon,
-		app.PublisherId,
-		pq.StringArray(app.ScreenShots),
-		app.Category,
-		pq.StringArray(app.Tags),
-		app.Rating,
-		app.Downloads,
-	).Scan(&app.Id, &app.CreatedAt, &app.UpdatedAt, &app.DeletedAt)
+	return withTx(ctx, "create app", func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, query,
+			app.Name,
+			app.Description,
+			app.Status,
+			app.Uri,
+			app.Icon,
+			app.PublisherId,
+			pq.StringArray(app.ScreenShots),
+			app.Category,
+			pq.StringArray(app.Tags),
+			app.Rating,
+			app.Downloads,
+			app.ThemeColor,
+			app.BackgroundColor,
+			app.Icons,
+		).Scan(&app.Id, &app.CreatedAt, &app.UpdatedAt, &app.DeletedAt)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "create app", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if err := insertEvent(ctx, tx, models.EventAppCreated, "app", app.Id, app); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "create app event", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		// App tạo thẳng ở trạng thái published cũng là một lần publish
+		if app.Status == models.AppStatusPublished {
+			if err := insertEvent(ctx, tx, models.EventAppPublished, "app", app.Id, app); err != nil {
+				logger.ErrorContext(ctx, "DB error", "op", "create app event", "error", err)
+				return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+			}
+		}
+		return nil
+	})
 }
 
-func GetAppById(id string) (models.App, error) {
+func GetAppById(ctx context.Context, id string) (models.App, error) {
 	db := configs.DB
 	var app models.App
-	err := db.Get(&app, "SELECT * FROM apps WHERE id = $1 AND deleted_at IS NULL", id)
+	err := db.GetContext(ctx, &app, "SELECT * FROM apps WHERE id = $1 AND deleted_at IS NULL", id)
 	if err != nil {
-		log.Printf("DB error (get app by id): %v", err)
+		logger.ErrorContext(ctx, "DB error", "op", "get app by id", "error", err)
 		return app, errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
 	}
 	return app, nil
 }
 
-func GetAllApps(limit, offset int) ([]models.App, error) {
+// AppSortOrders là các giá trị sort của danh sách app và mệnh đề ORDER BY tương ứng
+var AppSortOrders = map[string]string{
+	"top":      "rating_score DESC, rating_count DESC, favorite_count DESC",
+	"trending": "trending_score DESC, rating_score DESC",
+}
+
+func GetAllApps(ctx context.Context, limit, offset int, sort string) ([]models.App, error) {
 	db := configs.DB
 	var apps []models.App
 	query := "SELECT * FROM apps WHERE deleted_at IS NULL"
+	if order, ok := AppSortOrders[sort]; ok {
+		query += " ORDER BY " + order
+	}
 	if limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", limit)
 	}
 	if offset > 0 {
 		query += fmt.Sprintf(" OFFSET %d", offset)
 	}
-	err := db.Select(&apps, query)
+	err := db.SelectContext(ctx, &apps, query)
 	if err != nil {
-		log.Printf("DB error (get all apps): %v", err)
+		logger.ErrorContext(ctx, "DB error", "op", "get all apps", "error", err)
 		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
 	}
 	return apps, nil
 }
 
-func UpdateApp(id string, updates map[string]interface{}) error {
-	db := configs.DB
+func UpdateApp(ctx context.Context, id string, updates map[string]interface{}) error {
 	if len(updates) == 0 {
 		return nil
 	}
@@ -77,30 +108,59 @@ func UpdateApp(id string, updates map[string]interface{}) error {
 	}
 	setClause += ", updated_at = NOW()"
 	args = append(args, id)
-	query := fmt.Sprintf("UPDATE apps SET %s WHERE id = $%d AND deleted_at IS NULL", setClause, idx)
-	res, err := db.Exec(query, args...)
-	if err != nil {
-		log.Printf("DB error (update app): %v", err)
-		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
-	}
-	rows, _ := res.RowsAffected()
-	if rows == 0 {
-		return errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
-	}
-	return nil
+	query := fmt.Sprintf("UPDATE apps SET %s WHERE id = $%d AND deleted_at IS NULL RETURNING *", setClause, idx)
+	return withTx(ctx, "update app", func(tx *sqlx.Tx) error {
+		var oldStatus sql.NullString
+		err := tx.GetContext(ctx, &oldStatus, "SELECT status FROM apps WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "update app", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		var app models.App
+		if err := tx.GetContext(ctx, &app, query, args...); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "update app", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if app.Status == models.AppStatusPublished && oldStatus.String != models.AppStatusPublished {
+			if err := insertEvent(ctx, tx, models.EventAppPublished, "app", app.Id, app); err != nil {
+				logger.ErrorContext(ctx, "DB error", "op", "update app event", "error", err)
+				return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+			}
+		}
+		return nil
+	})
 }
 
-func DeleteApp(id string) error {
+func DeleteApp(ctx context.Context, id string) error {
+	query := "UPDATE apps SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING *"
+	return withTx(ctx, "delete app", func(tx *sqlx.Tx) error {
+		var app models.App
+		err := tx.GetContext(ctx, &app, query, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "delete app", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if err := insertEvent(ctx, tx, models.EventAppDeleted, "app", app.Id, app); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "delete app event", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+}
+
+func GetAppsByPublisher(ctx context.Context, publisherId string) ([]models.App, error) {
 	db := configs.DB
-	query := "UPDATE apps SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL"
-	res, err := db.Exec(query, id)
+	apps := []models.App{}
+	err := db.SelectContext(ctx, &apps, "SELECT * FROM apps WHERE publisher_id = $1 AND deleted_at IS NULL ORDER BY created_at", publisherId)
 	if err != nil {
-		log.Printf("DB error (delete app): %v", err)
-		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
-	}
-	rows, _ := res.RowsAffected()
-	if rows == 0 {
-		return errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
+		logger.ErrorContext(ctx, "DB error", "op", "get apps by publisher", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
 	}
-	return nil
+	return apps, nil
 }
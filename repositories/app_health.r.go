@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// ClaimAppsForHealthCheck lấy các app published tới lượt probe và đặt luôn
+// health_checked_at để các instance khác không probe trùng
+func ClaimAppsForHealthCheck(ctx context.Context, interval time.Duration, limit int) ([]models.App, error) {
+	db := configs.DB
+	apps := []models.App{}
+	query := `UPDATE apps SET health_checked_at = NOW()
+		WHERE id IN (
+			SELECT id FROM apps
+			WHERE deleted_at IS NULL AND status = 'published'
+				AND (health_checked_at IS NULL OR health_checked_at <= NOW() - make_interval(secs => $1))
+			ORDER BY health_checked_at NULLS FIRST FOR UPDATE SKIP LOCKED LIMIT $2
+		)
+		RETURNING *`
+	err := db.SelectContext(ctx, &apps, query, interval.Seconds(), limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "claim apps for health check", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return apps, nil
+}
+
+// RecordAppHealthCheck lưu kết quả probe và cập nhật trạng thái của app: sau threshold
+// lần lỗi liên tiếp app thành unhealthy (phát app.unhealthy), probe thành công thì
+// về healthy (phát app.recovered nếu trước đó unhealthy)
+func RecordAppHealthCheck(ctx context.Context, check *models.AppHealthCheck, threshold int) error {
+	return withTx(ctx, "record app health check", func(tx *sqlx.Tx) error {
+		var oldStatus string
+		err := tx.GetContext(ctx, &oldStatus, "SELECT health_status FROM apps WHERE id = $1 FOR UPDATE", check.AppId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "record app health check", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		err = tx.GetContext(ctx, check,
+			`INSERT INTO app_health_checks (app_id, ok, status_code, response_ms, error, checked_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+			RETURNING *`,
+			check.AppId, check.Ok, check.StatusCode, check.ResponseMs, check.Error)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "insert app health check", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		query := `UPDATE apps SET
+				health_failures = CASE WHEN $1 THEN 0 ELSE health_failures + 1 END,
+				health_status = CASE
+					WHEN $1 THEN 'healthy'
+					WHEN health_failures + 1 >= $2 THEN 'unhealthy'
+					ELSE health_status END,
+				health_checked_at = $3
+			WHERE id = $4
+			RETURNING *`
+		var app models.App
+		if err := tx.GetContext(ctx, &app, query, check.Ok, threshold, check.CheckedAt, check.AppId); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "update app health", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		eventType := ""
+		switch {
+		case app.HealthStatus == models.AppHealthUnhealthy && oldStatus != models.AppHealthUnhealthy:
+			eventType = models.EventAppUnhealthy
+		case app.HealthStatus == models.AppHealthHealthy && oldStatus == models.AppHealthUnhealthy:
+			eventType = models.EventAppRecovered
+		}
+		if eventType == "" {
+			return nil
+		}
+		payload := models.AppHealthEvent{
+			AppId:       app.Id,
+			PublisherId: app.PublisherId,
+			Uri:         app.Uri,
+			Failures:    app.HealthFailures,
+			Error:       check.Error.String,
+		}
+		if err := insertEvent(ctx, tx, eventType, "app", app.Id, payload); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "app health event", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+}
+
+// GetAppUptime tính tỉ lệ probe thành công trong 24h, 7 ngày, 30 ngày và
+// thời gian phản hồi trung bình trong 24h. Chưa có probe thì giá trị là null.
+func GetAppUptime(ctx context.Context, appId string) (models.AppUptime, error) {
+	db := configs.DB
+	var uptime models.AppUptime
+	query := `SELECT
+			AVG(ok::int) FILTER (WHERE checked_at > NOW() - INTERVAL '24 hours') AS uptime_24h,
+			AVG(ok::int) FILTER (WHERE checked_at > NOW() - INTERVAL '7 days') AS uptime_7d,
+			AVG(ok::int) AS uptime_30d,
+			AVG(response_ms) FILTER (WHERE ok AND checked_at > NOW() - INTERVAL '24 hours') AS avg_response_ms
+		FROM app_health_checks
+		WHERE app_id = $1 AND checked_at > NOW() - INTERVAL '30 days'`
+	if err := db.GetContext(ctx, &uptime, query, appId); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get app uptime", "error", err)
+		return uptime, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return uptime, nil
+}
+
+func PruneAppHealthChecks(ctx context.Context, retention time.Duration) (int64, error) {
+	db := configs.DB
+	res, err := db.ExecContext(ctx, "DELETE FROM app_health_checks WHERE checked_at < NOW() - make_interval(secs => $1)", retention.Seconds())
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "prune app health checks", "error", err)
+		return 0, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return res.RowsAffected()
+}
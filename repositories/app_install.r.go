@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// RecordAppInstall lưu lượt cài và tăng apps.downloads trong cùng transaction. Nếu installer đã
+// có lượt cài cùng source cho app trong window thì trả về lượt cài đó với Recorded = false.
+func RecordAppInstall(ctx context.Context, install *models.AppInstall, window time.Duration) error {
+	return withTx(ctx, "record app install", func(tx *sqlx.Tx) error {
+		// Khoá dòng app để hai request đồng thời của cùng installer không cùng được tính
+		var id string
+		err := tx.GetContext(ctx, &id, "SELECT id FROM apps WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", install.AppId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "record app install", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		err = tx.GetContext(ctx, install, `SELECT * FROM app_installs
+			WHERE app_id = $1 AND installer = $2 AND source = $3 AND created_at > NOW() - make_interval(secs => $4)
+			ORDER BY created_at DESC LIMIT 1`, install.AppId, install.Installer, install.Source, window.Seconds())
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			logger.ErrorContext(ctx, "DB error", "op", "record app install", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE apps SET downloads = downloads + 1 WHERE id = $1", install.AppId); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "record app install", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		query := `INSERT INTO app_installs (app_id, user_id, installer, source, created_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			RETURNING *`
+		if err := tx.GetContext(ctx, install, query, install.AppId, install.UserId, install.Installer, install.Source); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "record app install", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		install.Recorded = true
+		return nil
+	})
+}
+
+// GetAppInstallsByUser trả về lịch sử cài app của user, mới trước
+func GetAppInstallsByUser(ctx context.Context, userId string) ([]models.AppInstall, error) {
+	db := configs.DB
+	installs := []models.AppInstall{}
+	err := db.SelectContext(ctx, &installs, "SELECT * FROM app_installs WHERE user_id = $1 ORDER BY created_at DESC", userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get installs by user", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return installs, nil
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// claimApkPackage gán package name cho publisher sở hữu app. Package đã thuộc publisher khác
+// (PRIMARY KEY package_name, lỗi 23505) trả về APK_PACKAGE_CONFLICT; claim của publisher không
+// còn app nào dùng package đó được giải phóng trước.
+func claimApkPackage(ctx context.Context, tx *sqlx.Tx, appId, packageName string) error {
+	var publisherId string
+	if err := tx.GetContext(ctx, &publisherId, "SELECT publisher_id FROM apps WHERE id = $1", appId); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "claim apk package", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM apk_packages p WHERE p.package_name = $1 AND p.publisher_id <> $2
+		AND NOT EXISTS (
+			SELECT 1 FROM app_versions v JOIN apps a ON a.id = v.app_id
+			WHERE v.package_name = p.package_name AND a.publisher_id = p.publisher_id AND a.deleted_at IS NULL
+		)`, packageName, publisherId)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `INSERT INTO apk_packages (package_name, publisher_id, created_at) VALUES ($1, $2, NOW())
+			ON CONFLICT (package_name, publisher_id) DO NOTHING`, packageName, publisherId)
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return errors.New(configs.GetErrString(configs.ErrorCode_APK_PACKAGE_CONFLICT))
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "claim apk package", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func insertAppVersion(ctx context.Context, tx *sqlx.Tx, v *models.AppVersion, installUri string) error {
+	if err := claimApkPackage(ctx, tx, v.AppId, v.PackageName); err != nil {
+		return err
+	}
+	query := `INSERT INTO app_versions (app_id, build_id, source, apk_key, apk_sha256, apk_size, package_name,
+			version_code, version_name, min_sdk, target_sdk, permissions, cert_sha256, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW())
+		RETURNING id, created_at`
+	err := tx.QueryRowxContext(ctx, query, v.AppId, v.BuildId, v.Source, v.ApkKey, v.ApkSha256, v.ApkSize, v.PackageName,
+		v.VersionCode, v.VersionName, v.MinSdk, v.TargetSdk, v.Permissions, v.CertSha256).Scan(&v.Id, &v.CreatedAt)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, "UPDATE apps SET android_install_uri = $1, updated_at = NOW() WHERE id = $2", installUri, v.AppId)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "insert app version", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+// CreateAppVersion lưu version do publisher upload và trỏ android_install_uri của app tới link tải
+func CreateAppVersion(ctx context.Context, v *models.AppVersion, installUri string) error {
+	return withTx(ctx, "create app version", func(tx *sqlx.Tx) error {
+		return insertAppVersion(ctx, tx, v, installUri)
+	})
+}
+
+func GetLatestAppVersion(ctx context.Context, appId string) (models.AppVersion, error) {
+	db := configs.DB
+	var v models.AppVersion
+	err := db.GetContext(ctx, &v, "SELECT * FROM app_versions WHERE app_id = $1 ORDER BY created_at DESC LIMIT 1", appId)
+	if err != nil {
+		return v, errors.New(configs.GetErrString(configs.ErrorCode_APK_NOT_AVAILABLE))
+	}
+	return v, nil
+}
+
+// IsPackageTaken cho biết package name đã thuộc về app của publisher khác chưa. Chỉ dùng để từ chối
+// sớm trước khi lưu APK; insertAppVersion mới là nơi chặn chắc chắn
+func IsPackageTaken(ctx context.Context, packageName, publisherId string) (bool, error) {
+	db := configs.DB
+	var taken bool
+	query := `SELECT EXISTS (
+		SELECT 1 FROM app_versions v JOIN apps a ON a.id = v.app_id
+		WHERE v.package_name = $1 AND a.publisher_id <> $2 AND a.deleted_at IS NULL
+	)`
+	if err := db.GetContext(ctx, &taken, query, packageName, publisherId); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "check package owner", "error", err)
+		return false, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return taken, nil
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+func CreateBuild(ctx context.Context, build *models.Build) error {
+	db := configs.DB
+	query := `INSERT INTO builds (app_id, status, created_at, updated_at)
+		VALUES ($1, 'queued', NOW(), NOW())
+		RETURNING *`
+	err := db.GetContext(ctx, build, query, build.AppId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "create build", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func GetBuildById(ctx context.Context, id string) (models.Build, error) {
+	db := configs.DB
+	var build models.Build
+	err := db.GetContext(ctx, &build, "SELECT * FROM builds WHERE id = $1", id)
+	if err != nil {
+		return build, errors.New(configs.GetErrString(configs.ErrorCode_BUILD_NOT_FOUND))
+	}
+	return build, nil
+}
+
+// GetStaleBuilds trả về các build vẫn queued sau khoảng thời gian timeout mà chưa nhận được run nào;
+// build đã có run thành công do worker mirror xử lý và tự failed khi hết lượt thử
+func GetStaleBuilds(ctx context.Context, timeout time.Duration) ([]models.Build, error) {
+	db := configs.DB
+	builds := []models.Build{}
+	query := `SELECT * FROM builds
+		WHERE status = 'queued' AND workflow_run_id IS NULL AND created_at < NOW() - make_interval(secs => $1)`
+	err := db.SelectContext(ctx, &builds, query, timeout.Seconds())
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get stale builds", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return builds, nil
+}
+
+// RecordBuildRun lưu run thành công của build đang queued để worker mirror artifact sau.
+// Trả về false nếu build đã có run hoặc đã xong (GitHub gửi lại webhook).
+func RecordBuildRun(ctx context.Context, build *models.Build) (bool, error) {
+	db := configs.DB
+	query := `UPDATE builds SET workflow_run_id = $2, logs_url = $3, artifacts_url = $4, next_mirror_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'queued' AND workflow_run_id IS NULL
+		RETURNING *`
+	err := db.GetContext(ctx, build, query, build.Id, build.WorkflowRunId, build.LogsUrl, build.ArtifactsUrl)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "record build run", "error", err)
+		return false, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return true, nil
+}
+
+// ClaimMirrorBuilds nhận tối đa limit build đến hạn mirror, tăng mirror_attempts và giữ chúng
+// trong lease để worker khác không nhận trùng; tiến trình chết giữa chừng thì build được nhận lại sau lease
+func ClaimMirrorBuilds(ctx context.Context, limit int, lease time.Duration) ([]models.Build, error) {
+	db := configs.DB
+	builds := []models.Build{}
+	query := `UPDATE builds SET next_mirror_at = NOW() + make_interval(secs => $1), mirror_attempts = mirror_attempts + 1
+		WHERE id IN (
+			SELECT id FROM builds WHERE status = 'queued' AND next_mirror_at <= NOW()
+			ORDER BY next_mirror_at FOR UPDATE SKIP LOCKED LIMIT $2
+		)
+		RETURNING *`
+	err := db.SelectContext(ctx, &builds, query, lease.Seconds(), limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "claim mirror builds", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return builds, nil
+}
+
+// RetryBuildMirror hẹn lần mirror tiếp theo và ghi lại lỗi của lần vừa thử
+func RetryBuildMirror(ctx context.Context, id string, next time.Time, reason string) error {
+	db := configs.DB
+	query := `UPDATE builds SET next_mirror_at = $2, error = $3, updated_at = NOW()
+		WHERE id = $1 AND status = 'queued'`
+	if _, err := db.ExecContext(ctx, query, id, next, reason); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "retry build mirror", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+// CompleteBuild ghi kết quả của build đang queued: thành công thì lưu version APK,
+// cập nhật android_install_uri của app và phát build.completed, ngược lại phát build.failed.
+// Trả về false nếu build đã có kết quả từ trước (GitHub có thể gửi lại webhook).
+func CompleteBuild(ctx context.Context, build *models.Build, version *models.AppVersion, installUri string) (bool, error) {
+	completed := false
+	err := withTx(ctx, "complete build", func(tx *sqlx.Tx) error {
+		var status string
+		err := tx.GetContext(ctx, &status, "SELECT status FROM builds WHERE id = $1 FOR UPDATE", build.Id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_BUILD_NOT_FOUND))
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "complete build", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if status != models.BuildStatusQueued {
+			return nil
+		}
+		if version != nil {
+			if err := insertAppVersion(ctx, tx, version, installUri); err != nil {
+				return err
+			}
+			build.VersionId = sql.NullString{String: version.Id, Valid: true}
+		}
+		query := `UPDATE builds SET status = $1, workflow_run_id = $2, artifact_url = $3, version_id = $4, logs_url = $5,
+				error = $6, next_mirror_at = NULL, updated_at = NOW(), completed_at = NOW()
+			WHERE id = $7
+			RETURNING *`
+		err = tx.GetContext(ctx, build, query, build.Status, build.WorkflowRunId, build.ArtifactUrl, build.VersionId,
+			build.LogsUrl, build.Error, build.Id)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "complete build", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		var publisherId string
+		if err := tx.GetContext(ctx, &publisherId, "SELECT publisher_id FROM apps WHERE id = $1", build.AppId); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "complete build", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		event := models.BuildEvent{
+			BuildId:     build.Id,
+			AppId:       build.AppId,
+			PublisherId: publisherId,
+			Reason:      build.Error.String,
+			LogsUrl:     build.LogsUrl.String,
+		}
+		eventType := models.EventBuildFailed
+		if build.Status == models.BuildStatusSucceeded {
+			eventType = models.EventBuildCompleted
+			event.AndroidInstallUri = installUri
+		}
+		if err := insertEvent(ctx, tx, eventType, "app", build.AppId, event); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "complete build event", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		completed = true
+		return nil
+	})
+	return completed, err
+}
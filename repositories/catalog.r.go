@@ -0,0 +1,215 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// catalogWriteError chuyển lỗi trùng slug thành SLUG_ALREADY_EXISTS
+func catalogWriteError(ctx context.Context, op string, err error, notFound configs.ErrorCode) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return errors.New(configs.GetErrString(configs.ErrorCode_SLUG_ALREADY_EXISTS))
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New(configs.GetErrString(notFound))
+	}
+	logger.ErrorContext(ctx, "DB error", "op", op, "error", err)
+	return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+}
+
+// GetCategories trả về mọi category kèm số app published, app của subcategory
+// được tính cả vào category cha
+func GetCategories(ctx context.Context) ([]models.Category, error) {
+	db := configs.DB
+	categories := []models.Category{}
+	query := `SELECT c.*, COUNT(a.id) AS app_count
+		FROM categories c
+		LEFT JOIN apps a ON a.deleted_at IS NULL AND a.status = 'published'
+			AND (a.category = c.slug OR a.category IN (SELECT s.slug FROM categories s WHERE s.parent_id = c.id))
+		GROUP BY c.id
+		ORDER BY c.position, c.slug`
+	if err := db.SelectContext(ctx, &categories, query); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get categories", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return categories, nil
+}
+
+func GetCategoryById(ctx context.Context, id string) (models.Category, error) {
+	db := configs.DB
+	var category models.Category
+	if err := db.GetContext(ctx, &category, "SELECT * FROM categories WHERE id = $1", id); err != nil {
+		return category, errors.New(configs.GetErrString(configs.ErrorCode_CATEGORY_NOT_FOUND))
+	}
+	return category, nil
+}
+
+func GetCategoryBySlug(ctx context.Context, slug string) (models.Category, error) {
+	db := configs.DB
+	var category models.Category
+	if err := db.GetContext(ctx, &category, "SELECT * FROM categories WHERE slug = $1", slug); err != nil {
+		return category, errors.New(configs.GetErrString(configs.ErrorCode_CATEGORY_NOT_FOUND))
+	}
+	return category, nil
+}
+
+func CreateCategory(ctx context.Context, category *models.Category) error {
+	db := configs.DB
+	query := `INSERT INTO categories (slug, names, icon, position, parent_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		RETURNING *`
+	err := db.GetContext(ctx, category, query, category.Slug, category.Names, category.Icon, category.Position, category.ParentId)
+	if err != nil {
+		return catalogWriteError(ctx, "create category", err, configs.ErrorCode_CATEGORY_NOT_FOUND)
+	}
+	return nil
+}
+
+// UpdateCategory cập nhật category; đổi slug thì apps.category đổi theo trong cùng transaction
+func UpdateCategory(ctx context.Context, category *models.Category) error {
+	return withTx(ctx, "update category", func(tx *sqlx.Tx) error {
+		var oldSlug string
+		err := tx.GetContext(ctx, &oldSlug, "SELECT slug FROM categories WHERE id = $1 FOR UPDATE", category.Id)
+		if err != nil {
+			return catalogWriteError(ctx, "update category", err, configs.ErrorCode_CATEGORY_NOT_FOUND)
+		}
+		query := `UPDATE categories SET slug = $1, names = $2, icon = $3, position = $4, parent_id = $5, updated_at = NOW()
+			WHERE id = $6
+			RETURNING *`
+		err = tx.GetContext(ctx, category, query, category.Slug, category.Names, category.Icon, category.Position,
+			category.ParentId, category.Id)
+		if err != nil {
+			return catalogWriteError(ctx, "update category", err, configs.ErrorCode_CATEGORY_NOT_FOUND)
+		}
+		if oldSlug != category.Slug {
+			if _, err := tx.ExecContext(ctx, "UPDATE apps SET category = $1 WHERE category = $2", category.Slug, oldSlug); err != nil {
+				logger.ErrorContext(ctx, "DB error", "op", "rename app category", "error", err)
+				return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteCategory xoá category chưa có subcategory và chưa có app nào dùng
+func DeleteCategory(ctx context.Context, id string) error {
+	return withTx(ctx, "delete category", func(tx *sqlx.Tx) error {
+		var inUse bool
+		query := `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)
+			OR EXISTS (SELECT 1 FROM apps a JOIN categories c ON a.category = c.slug WHERE c.id = $1 AND a.deleted_at IS NULL)`
+		if err := tx.GetContext(ctx, &inUse, query, id); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "delete category", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if inUse {
+			return errors.New(configs.GetErrString(configs.ErrorCode_CATEGORY_IN_USE))
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "delete category", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return errors.New(configs.GetErrString(configs.ErrorCode_CATEGORY_NOT_FOUND))
+		}
+		return nil
+	})
+}
+
+// GetTags trả về bộ tag kèm số app đang dùng, tag dùng nhiều nhất trước
+func GetTags(ctx context.Context) ([]models.Tag, error) {
+	db := configs.DB
+	tags := []models.Tag{}
+	query := `SELECT t.*, COUNT(a.id) AS usage_count
+		FROM tags t
+		LEFT JOIN apps a ON t.slug = ANY(a.tags) AND a.deleted_at IS NULL
+		GROUP BY t.id
+		ORDER BY usage_count DESC, t.slug`
+	if err := db.SelectContext(ctx, &tags, query); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get tags", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return tags, nil
+}
+
+// GetMissingTags trả về các slug trong danh sách không có trong bảng tags
+func GetMissingTags(ctx context.Context, slugs []string) ([]string, error) {
+	db := configs.DB
+	missing := []string{}
+	query := "SELECT s FROM unnest($1::text[]) AS s WHERE s NOT IN (SELECT slug FROM tags)"
+	if err := db.SelectContext(ctx, &missing, query, pq.StringArray(slugs)); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get missing tags", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return missing, nil
+}
+
+func CreateTag(ctx context.Context, tag *models.Tag) error {
+	db := configs.DB
+	query := `INSERT INTO tags (slug, name, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		RETURNING *`
+	if err := db.GetContext(ctx, tag, query, tag.Slug, tag.Name); err != nil {
+		return catalogWriteError(ctx, "create tag", err, configs.ErrorCode_TAG_NOT_FOUND)
+	}
+	return nil
+}
+
+// UpdateTag đổi tên hiển thị và/hoặc slug; đổi slug thì thay luôn trong apps.tags
+func UpdateTag(ctx context.Context, tag *models.Tag) error {
+	return withTx(ctx, "update tag", func(tx *sqlx.Tx) error {
+		var oldSlug string
+		err := tx.GetContext(ctx, &oldSlug, "SELECT slug FROM tags WHERE id = $1 FOR UPDATE", tag.Id)
+		if err != nil {
+			return catalogWriteError(ctx, "update tag", err, configs.ErrorCode_TAG_NOT_FOUND)
+		}
+		query := "UPDATE tags SET slug = $1, name = $2, updated_at = NOW() WHERE id = $3 RETURNING *"
+		if err := tx.GetContext(ctx, tag, query, tag.Slug, tag.Name, tag.Id); err != nil {
+			return catalogWriteError(ctx, "update tag", err, configs.ErrorCode_TAG_NOT_FOUND)
+		}
+		if oldSlug != tag.Slug {
+			_, err := tx.ExecContext(ctx, "UPDATE apps SET tags = array_replace(tags, $1, $2) WHERE $1 = ANY(tags)", oldSlug, tag.Slug)
+			if err != nil {
+				logger.ErrorContext(ctx, "DB error", "op", "rename app tag", "error", err)
+				return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteTag xoá tag khỏi bộ tag và khỏi mọi app đang gắn
+func DeleteTag(ctx context.Context, id string) error {
+	return withTx(ctx, "delete tag", func(tx *sqlx.Tx) error {
+		var slug string
+		err := tx.GetContext(ctx, &slug, "DELETE FROM tags WHERE id = $1 RETURNING slug", id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_TAG_NOT_FOUND))
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "delete tag", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE apps SET tags = array_remove(tags, $1) WHERE $1 = ANY(tags)", slug); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "remove app tag", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+}
+
+func GetTagById(ctx context.Context, id string) (models.Tag, error) {
+	db := configs.DB
+	var tag models.Tag
+	if err := db.GetContext(ctx, &tag, "SELECT * FROM tags WHERE id = $1", id); err != nil {
+		return tag, errors.New(configs.GetErrString(configs.ErrorCode_TAG_NOT_FOUND))
+	}
+	return tag, nil
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// collectionApp là một dòng app kèm collection chứa nó
+type collectionApp struct {
+	CollectionId string `db:"collection_id"`
+	models.App
+}
+
+// GetCollections trả về mọi collection curated; activeOnly chỉ lấy collection
+// đang trong lịch hiển thị
+func GetCollections(ctx context.Context, activeOnly bool) ([]models.Collection, error) {
+	db := configs.DB
+	collections := []models.Collection{}
+	query := "SELECT * FROM collections"
+	if activeOnly {
+		query += " WHERE (starts_at IS NULL OR starts_at <= NOW()) AND (ends_at IS NULL OR ends_at > NOW())"
+	}
+	query += " ORDER BY position, created_at"
+	if err := db.SelectContext(ctx, &collections, query); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get collections", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return collections, nil
+}
+
+func GetCollectionById(ctx context.Context, id string) (models.Collection, error) {
+	db := configs.DB
+	var collection models.Collection
+	if err := db.GetContext(ctx, &collection, "SELECT * FROM collections WHERE id = $1", id); err != nil {
+		return collection, errors.New(configs.GetErrString(configs.ErrorCode_COLLECTION_NOT_FOUND))
+	}
+	return collection, nil
+}
+
+// GetCollectionApps trả về app của từng collection theo thứ tự admin sắp xếp,
+// mỗi collection tối đa limit app (limit <= 0: không giới hạn). publishedOnly
+// bỏ qua app chưa published.
+func GetCollectionApps(ctx context.Context, collectionIds []string, limit int, publishedOnly bool) (map[string][]models.App, error) {
+	db := configs.DB
+	rows := []collectionApp{}
+	query := `SELECT x.collection_id, a.*
+		FROM (
+			SELECT ca.collection_id, ca.app_id, ca.position,
+				ROW_NUMBER() OVER (PARTITION BY ca.collection_id ORDER BY ca.position) AS rn
+			FROM collection_apps ca
+			JOIN apps p ON p.id = ca.app_id AND p.deleted_at IS NULL AND (NOT $2 OR p.status = 'published')
+			WHERE ca.collection_id = ANY($1)
+		) x
+		JOIN apps a ON a.id = x.app_id
+		WHERE $3 <= 0 OR x.rn <= $3
+		ORDER BY x.collection_id, x.position`
+	if err := db.SelectContext(ctx, &rows, query, pq.StringArray(collectionIds), publishedOnly, limit); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get collection apps", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	apps := map[string][]models.App{}
+	for _, row := range rows {
+		apps[row.CollectionId] = append(apps[row.CollectionId], row.App)
+	}
+	return apps, nil
+}
+
+func CreateCollection(ctx context.Context, collection *models.Collection) error {
+	db := configs.DB
+	query := `INSERT INTO collections (slug, titles, banner_url, position, starts_at, ends_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING *`
+	err := db.GetContext(ctx, collection, query, collection.Slug, collection.Titles, collection.BannerUrl,
+		collection.Position, collection.StartsAt, collection.EndsAt)
+	if err != nil {
+		return catalogWriteError(ctx, "create collection", err, configs.ErrorCode_COLLECTION_NOT_FOUND)
+	}
+	return nil
+}
+
+func UpdateCollection(ctx context.Context, collection *models.Collection) error {
+	db := configs.DB
+	query := `UPDATE collections SET slug = $1, titles = $2, banner_url = $3, position = $4, starts_at = $5, ends_at = $6,
+			updated_at = NOW()
+		WHERE id = $7
+		RETURNING *`
+	err := db.GetContext(ctx, collection, query, collection.Slug, collection.Titles, collection.BannerUrl,
+		collection.Position, collection.StartsAt, collection.EndsAt, collection.Id)
+	if err != nil {
+		return catalogWriteError(ctx, "update collection", err, configs.ErrorCode_COLLECTION_NOT_FOUND)
+	}
+	return nil
+}
+
+func DeleteCollection(ctx context.Context, id string) error {
+	db := configs.DB
+	res, err := db.ExecContext(ctx, "DELETE FROM collections WHERE id = $1", id)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "delete collection", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New(configs.GetErrString(configs.ErrorCode_COLLECTION_NOT_FOUND))
+	}
+	return nil
+}
+
+// SetCollectionApps thay toàn bộ danh sách app của collection, thứ tự theo appIds
+func SetCollectionApps(ctx context.Context, collectionId string, appIds []string) error {
+	return withTx(ctx, "set collection apps", func(tx *sqlx.Tx) error {
+		var id string
+		if err := tx.GetContext(ctx, &id, "SELECT id FROM collections WHERE id = $1 FOR UPDATE", collectionId); err != nil {
+			return errors.New(configs.GetErrString(configs.ErrorCode_COLLECTION_NOT_FOUND))
+		}
+		var found int
+		query := "SELECT COUNT(*) FROM apps WHERE id = ANY($1) AND deleted_at IS NULL"
+		if err := tx.GetContext(ctx, &found, query, pq.StringArray(appIds)); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "set collection apps", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if found != len(appIds) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM collection_apps WHERE collection_id = $1", collectionId); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "set collection apps", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		query = `INSERT INTO collection_apps (collection_id, app_id, position)
+			SELECT $1, app_id, position FROM unnest($2::uuid[]) WITH ORDINALITY AS t(app_id, position)`
+		if _, err := tx.ExecContext(ctx, query, collectionId, pq.StringArray(appIds)); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "set collection apps", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE collections SET updated_at = NOW() WHERE id = $1", collectionId)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "set collection apps", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+}
+
+// GetTopRatedApps trả về app published có đánh giá, rating_score (trung bình Bayes) cao nhất trước
+func GetTopRatedApps(ctx context.Context, limit int) ([]models.App, error) {
+	return selectStorefrontApps(ctx, "top rated apps", `SELECT * FROM apps
+		WHERE deleted_at IS NULL AND status = 'published' AND rating_count > 0
+		ORDER BY rating_score DESC, rating_count DESC, favorite_count DESC LIMIT $1`, limit)
+}
+
+// GetTrendingApps trả về app published có trending_score cao nhất
+func GetTrendingApps(ctx context.Context, limit int) ([]models.App, error) {
+	return selectStorefrontApps(ctx, "trending apps", `SELECT * FROM apps
+		WHERE deleted_at IS NULL AND status = 'published' AND trending_score > 0
+		ORDER BY trending_score DESC, rating_score DESC LIMIT $1`, limit)
+}
+
+// GetNewApps trả về app published mới nhất
+func GetNewApps(ctx context.Context, limit int) ([]models.App, error) {
+	return selectStorefrontApps(ctx, "new apps", `SELECT * FROM apps
+		WHERE deleted_at IS NULL AND status = 'published'
+		ORDER BY created_at DESC LIMIT $1`, limit)
+}
+
+func selectStorefrontApps(ctx context.Context, op, query string, args ...interface{}) ([]models.App, error) {
+	db := configs.DB
+	apps := []models.App{}
+	if err := db.SelectContext(ctx, &apps, query, args...); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get "+op, "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return apps, nil
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// CreateDataExport tạo export mới nếu user chưa có export đang chờ. Export kẹt ở processing
+// quá processingTimeout (worker chết giữa chừng) không chặn export mới.
+func CreateDataExport(ctx context.Context, userId string, processingTimeout time.Duration) (models.DataExport, error) {
+	db := configs.DB
+	var export models.DataExport
+	var pending int
+	query := `SELECT COUNT(*) FROM data_exports WHERE user_id = $1
+		AND (status = 'pending' OR (status = 'processing' AND COALESCE(started_at, created_at) > NOW() - make_interval(secs => $2)))`
+	err := db.GetContext(ctx, &pending, query, userId, processingTimeout.Seconds())
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "check pending export", "error", err)
+		return export, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if pending > 0 {
+		return export, errors.New(configs.GetErrString(configs.ErrorCode_EXPORT_ALREADY_PENDING))
+	}
+	err = db.GetContext(ctx, &export, "INSERT INTO data_exports (user_id, status, created_at) VALUES ($1, 'pending', NOW()) RETURNING *", userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "create export", "error", err)
+		return export, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return export, nil
+}
+
+// ClaimPendingDataExport chuyển một export đang chờ sang processing; export kẹt ở processing
+// quá processingTimeout được nhận lại. Trả về nil nếu không còn
+func ClaimPendingDataExport(ctx context.Context, processingTimeout time.Duration) (*models.DataExport, error) {
+	db := configs.DB
+	var export models.DataExport
+	query := `UPDATE data_exports SET status = 'processing', started_at = NOW()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending' OR (status = 'processing' AND COALESCE(started_at, created_at) <= NOW() - make_interval(secs => $1))
+			ORDER BY created_at FOR UPDATE SKIP LOCKED LIMIT 1
+		)
+		RETURNING *`
+	err := db.GetContext(ctx, &export, query, processingTimeout.Seconds())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "claim export", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return &export, nil
+}
+
+func CompleteDataExport(ctx context.Context, id, storageKey string, size int64, expiresAt time.Time) error {
+	db := configs.DB
+	query := `UPDATE data_exports SET status = 'ready', storage_key = $1, size = $2, expires_at = $3, completed_at = NOW()
+		WHERE id = $4`
+	_, err := db.ExecContext(ctx, query, storageKey, size, expiresAt, id)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "complete export", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func FailDataExport(ctx context.Context, id, reason string) error {
+	db := configs.DB
+	_, err := db.ExecContext(ctx, "UPDATE data_exports SET status = 'failed', error = $1, completed_at = NOW() WHERE id = $2", reason, id)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "fail export", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func GetDataExport(ctx context.Context, id, userId string) (models.DataExport, error) {
+	db := configs.DB
+	var export models.DataExport
+	err := db.GetContext(ctx, &export, "SELECT * FROM data_exports WHERE id = $1 AND user_id = $2", id, userId)
+	if err != nil {
+		return export, errors.New(configs.GetErrString(configs.ErrorCode_EXPORT_NOT_FOUND))
+	}
+	return export, nil
+}
+
+// GetReadyDataExportKey trả về storage key của export còn tải được
+func GetReadyDataExportKey(ctx context.Context, id string) (string, error) {
+	db := configs.DB
+	var storageKey string
+	err := db.GetContext(ctx, &storageKey, "SELECT storage_key FROM data_exports WHERE id = $1 AND status = 'ready' AND expires_at > NOW()", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errors.New(configs.GetErrString(configs.ErrorCode_EXPORT_LINK_INVALID))
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get export key", "error", err)
+		return "", errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return storageKey, nil
+}
+
+// ConsumeDataExport đánh dấu export đã tải để link chỉ dùng được một lần; chỉ một request thắng
+func ConsumeDataExport(ctx context.Context, id string) error {
+	db := configs.DB
+	query := `UPDATE data_exports SET status = 'downloaded', downloaded_at = NOW()
+		WHERE id = $1 AND status = 'ready' AND expires_at > NOW()`
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "consume export", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New(configs.GetErrString(configs.ErrorCode_EXPORT_LINK_INVALID))
+	}
+	return nil
+}
+
+// ExpireDataExports đánh dấu các export đã hết hạn và trả về storage key để xoá blob
+func ExpireDataExports(ctx context.Context) ([]string, error) {
+	db := configs.DB
+	keys := []string{}
+	query := `UPDATE data_exports SET status = 'expired'
+		WHERE status = 'ready' AND expires_at <= NOW()
+		RETURNING storage_key`
+	err := db.SelectContext(ctx, &keys, query)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "expire exports", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return keys, nil
+}
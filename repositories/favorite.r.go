@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// AddFavorite lưu app vào danh sách yêu thích của user và tăng apps.favorite_count.
+// Gọi lại với app đã có trong danh sách thì không làm gì.
+func AddFavorite(ctx context.Context, userId, appId string) error {
+	return withTx(ctx, "add favorite", func(tx *sqlx.Tx) error {
+		var id string
+		err := tx.GetContext(ctx, &id, "SELECT id FROM apps WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", appId)
+		if err != nil {
+			return errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
+		}
+		res, err := tx.ExecContext(ctx, `INSERT INTO favorites (user_id, app_id, created_at) VALUES ($1, $2, NOW())
+			ON CONFLICT (user_id, app_id) DO NOTHING`, userId, appId)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "add favorite", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE apps SET favorite_count = favorite_count + 1 WHERE id = $1", appId); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "add favorite count", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+}
+
+// RemoveFavorite bỏ app khỏi danh sách yêu thích; app không có trong danh sách thì không làm gì
+func RemoveFavorite(ctx context.Context, userId, appId string) error {
+	return withTx(ctx, "remove favorite", func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM favorites WHERE user_id = $1 AND app_id = $2", userId, appId)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "remove favorite", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE apps SET favorite_count = GREATEST(favorite_count - 1, 0) WHERE id = $1", appId)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "remove favorite count", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+}
+
+// GetFavoriteApps trả về app yêu thích của user, mới lưu trước
+func GetFavoriteApps(ctx context.Context, userId string, limit, offset int) ([]models.App, error) {
+	db := configs.DB
+	apps := []models.App{}
+	query := `SELECT a.* FROM favorites f JOIN apps a ON a.id = f.app_id
+		WHERE f.user_id = $1 AND a.deleted_at IS NULL
+		ORDER BY f.created_at DESC LIMIT $2 OFFSET $3`
+	if err := db.SelectContext(ctx, &apps, query, userId, limit, offset); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get favorite apps", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return apps, nil
+}
+
+func IsFavorite(ctx context.Context, userId, appId string) (bool, error) {
+	db := configs.DB
+	var exists bool
+	err := db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM favorites WHERE user_id = $1 AND app_id = $2)", userId, appId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.ErrorContext(ctx, "DB error", "op", "is favorite", "error", err)
+		return false, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return exists, nil
+}
+
+func GetFavoritesByUser(ctx context.Context, userId string) ([]models.Favorite, error) {
+	db := configs.DB
+	favorites := []models.Favorite{}
+	err := db.SelectContext(ctx, &favorites, "SELECT * FROM favorites WHERE user_id = $1 ORDER BY created_at", userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get favorites by user", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return favorites, nil
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"waheim.api/configs"
+)
+
+func PingDB(ctx context.Context) error {
+	return configs.DB.PingContext(ctx)
+}
+
+func GetSchemaVersion(ctx context.Context) (int, error) {
+	db := configs.DB
+	var version int
+	err := db.GetContext(ctx, &version, "SELECT version FROM schema_version LIMIT 1")
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get schema version", "error", err)
+		return 0, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return version, nil
+}
+
+// GetQueueDepth trả về số job đang chờ và tuổi (giây) của job cũ nhất trong một bảng hàng đợi
+func GetQueueDepth(ctx context.Context, table, pendingCondition string) (int, float64, error) {
+	db := configs.DB
+	var row struct {
+		Count  int     `db:"count"`
+		Oldest float64 `db:"oldest"`
+	}
+	query := "SELECT COUNT(*) AS count, COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0) AS oldest FROM " + table + " WHERE " + pendingCondition
+	err := db.GetContext(ctx, &row, query)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get queue depth", "table", table, "error", err)
+		return 0, 0, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return row.Count, row.Oldest, nil
+}
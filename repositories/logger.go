@@ -0,0 +1,5 @@
+package repositories
+
+import "waheim.api/configs"
+
+var logger = configs.Logger("repositories")
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// CreateNotification lưu thông báo nếu user không tắt loại này. Thông báo tạo từ event (có EventId)
+// chỉ được lưu một lần cho mỗi user. Trả về false nếu bị bỏ qua.
+func CreateNotification(ctx context.Context, n *models.Notification) (bool, error) {
+	db := configs.DB
+	if len(n.Data) == 0 {
+		n.Data = []byte("{}")
+	}
+	query := `INSERT INTO notifications (user_id, type, title, body, data, event_id, created_at)
+		SELECT $1, $2, $3, $4, $5, $6, NOW()
+		WHERE NOT EXISTS (
+			SELECT 1 FROM notification_preferences WHERE user_id = $1 AND type = $2 AND NOT enabled
+		)
+		ON CONFLICT (user_id, event_id) WHERE event_id IS NOT NULL DO NOTHING
+		RETURNING *`
+	rows, err := db.QueryxContext(ctx, query, n.UserId, n.Type, n.Title, n.Body, n.Data, n.EventId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "create notification", "error", err)
+		return false, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "create notification", "error", err)
+			return false, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return false, nil
+	}
+	if err := rows.StructScan(n); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "create notification", "error", err)
+		return false, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return true, nil
+}
+
+// GetNotifications trả về thông báo của user, mới trước
+func GetNotifications(ctx context.Context, userId string, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	db := configs.DB
+	notifications := []models.Notification{}
+	query := `SELECT * FROM notifications WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC LIMIT $3 OFFSET $4`
+	if err := db.SelectContext(ctx, &notifications, query, userId, unreadOnly, limit, offset); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get notifications", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return notifications, nil
+}
+
+func CountUnreadNotifications(ctx context.Context, userId string) (int, error) {
+	db := configs.DB
+	var count int
+	if err := db.GetContext(ctx, &count, "SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL", userId); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "count unread notifications", "error", err)
+		return 0, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return count, nil
+}
+
+// MarkNotificationRead đánh dấu đã đọc; thông báo đã đọc trước đó giữ nguyên read_at
+func MarkNotificationRead(ctx context.Context, userId, id string) error {
+	db := configs.DB
+	res, err := db.ExecContext(ctx, "UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2", id, userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "mark notification read", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New(configs.GetErrString(configs.ErrorCode_NOTIFICATION_NOT_FOUND))
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead đánh dấu mọi thông báo chưa đọc của user, trả về số thông báo được cập nhật
+func MarkAllNotificationsRead(ctx context.Context, userId string) (int64, error) {
+	db := configs.DB
+	res, err := db.ExecContext(ctx, "UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL", userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "mark all notifications read", "error", err)
+		return 0, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return res.RowsAffected()
+}
+
+// GetNotificationPreferences trả về các loại thông báo user đã chỉnh
+func GetNotificationPreferences(ctx context.Context, userId string) (map[string]bool, error) {
+	db := configs.DB
+	var rows []struct {
+		Type    string `db:"type"`
+		Enabled bool   `db:"enabled"`
+	}
+	if err := db.SelectContext(ctx, &rows, "SELECT type, enabled FROM notification_preferences WHERE user_id = $1", userId); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get notification preferences", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	prefs := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		prefs[r.Type] = r.Enabled
+	}
+	return prefs, nil
+}
+
+// SetNotificationPreferences ghi đè các loại được truyền vào, loại khác giữ nguyên
+func SetNotificationPreferences(ctx context.Context, userId string, prefs map[string]bool) error {
+	return withTx(ctx, "set notification preferences", func(tx *sqlx.Tx) error {
+		for notificationType, enabled := range prefs {
+			_, err := tx.ExecContext(ctx, `INSERT INTO notification_preferences (user_id, type, enabled, updated_at)
+				VALUES ($1, $2, $3, NOW())
+				ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()`,
+				userId, notificationType, enabled)
+			if err != nil {
+				logger.ErrorContext(ctx, "DB error", "op", "set notification preference", "error", err)
+				return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+			}
+		}
+		return nil
+	})
+}
+
+// PruneNotifications xoá thông báo đã đọc cũ hơn retention
+func PruneNotifications(ctx context.Context, retention time.Duration) (int64, error) {
+	db := configs.DB
+	res, err := db.ExecContext(ctx, "DELETE FROM notifications WHERE read_at IS NOT NULL AND created_at < NOW() - make_interval(secs => $1)", retention.Seconds())
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "prune notifications", "error", err)
+		return 0, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// insertEvent ghi domain event vào outbox bằng transaction của thay đổi tương ứng,
+// nên event chỉ tồn tại khi thay đổi đã commit
+func insertEvent(ctx context.Context, tx sqlx.ExecerContext, eventType, aggregateType, aggregateId string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO outbox (event_type, aggregate_type, aggregate_id, payload, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())`,
+		eventType, aggregateType, aggregateId, data)
+	return err
+}
+
+// ClaimOutboxEvents lấy các event đến hạn gửi và giữ chúng trong thời gian lease
+// để các relay khác không gửi trùng
+func ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]models.Event, error) {
+	db := configs.DB
+	events := []models.Event{}
+	query := `UPDATE outbox SET next_attempt_at = NOW() + make_interval(secs => $1)
+		WHERE id IN (
+			SELECT id FROM outbox WHERE delivered_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY created_at FOR UPDATE SKIP LOCKED LIMIT $2
+		)
+		RETURNING *`
+	err := db.SelectContext(ctx, &events, query, lease.Seconds(), limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "claim outbox events", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return events, nil
+}
+
+func MarkEventDelivered(ctx context.Context, id string) error {
+	db := configs.DB
+	_, err := db.ExecContext(ctx, "UPDATE outbox SET delivered_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1", id)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "mark event delivered", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func MarkEventFailed(ctx context.Context, id, reason string, retryAfter time.Duration) error {
+	db := configs.DB
+	query := `UPDATE outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id = $3`
+	_, err := db.ExecContext(ctx, query, reason, retryAfter.Seconds(), id)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "mark event failed", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+// withTx chạy fn trong một transaction, rollback nếu fn trả lỗi
+func withTx(ctx context.Context, op string, fn func(tx *sqlx.Tx) error) error {
+	tx, err := configs.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", op+" begin", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", op+" commit", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
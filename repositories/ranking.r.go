@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"waheim.api/configs"
+)
+
+// RefreshAppRankings tính lại điểm xếp hạng của mọi app:
+//   - rating_score = (prior * mean + tổng sao) / (prior + số đánh giá đang hiển thị), mean là điểm
+//     trung bình của toàn store, nên app ít đánh giá bị kéo về mức trung bình
+//   - trending_score = tổng lượt cài (1 điểm, mỗi installer chỉ tính lượt gần nhất), đánh giá (2 * sao / 5) và lượt yêu thích (1.5) trong window,
+//     mỗi sự kiện giảm một nửa giá trị sau mỗi halfLife
+func RefreshAppRankings(ctx context.Context, prior float64, halfLife, window time.Duration) (int64, error) {
+	db := configs.DB
+	query := `WITH stats AS (
+			SELECT COALESCE(AVG(r.stars), 0) AS mean
+			FROM ratings r JOIN apps a ON a.id = r.app_id
+			WHERE r.deleted_at IS NULL AND COALESCE(r.status, 'published') = 'published' AND a.deleted_at IS NULL
+		),
+		rated AS (
+			SELECT app_id, COUNT(*) AS n, SUM(stars) AS total FROM ratings WHERE deleted_at IS NULL AND ` + publishedRating + ` GROUP BY app_id
+		),
+		activity AS (
+			SELECT app_id, SUM(weight * power(0.5, EXTRACT(EPOCH FROM NOW() - created_at) / $2)) AS score
+			FROM (
+				SELECT * FROM (
+					SELECT DISTINCT ON (app_id, installer) app_id, 1.0 AS weight, created_at FROM app_installs
+					WHERE created_at > NOW() - make_interval(secs => $3)
+					ORDER BY app_id, installer, created_at DESC
+				) installs
+				UNION ALL
+				SELECT app_id, 2.0 * stars / 5, created_at FROM ratings
+				WHERE deleted_at IS NULL AND ` + publishedRating + ` AND created_at > NOW() - make_interval(secs => $3)
+				UNION ALL
+				SELECT app_id, 1.5, created_at FROM favorites
+				WHERE created_at > NOW() - make_interval(secs => $3)
+			) e
+			GROUP BY app_id
+		)
+		UPDATE apps a SET
+			rating_count = COALESCE(r.n, 0),
+			rating_score = CASE WHEN r.n IS NULL THEN 0 ELSE ($1 * s.mean + r.total) / ($1 + r.n) END,
+			trending_score = COALESCE(act.score, 0),
+			ranked_at = NOW()
+		FROM stats s, apps x
+		LEFT JOIN rated r ON r.app_id = x.id
+		LEFT JOIN activity act ON act.app_id = x.id
+		WHERE x.id = a.id AND a.deleted_at IS NULL`
+	res, err := db.ExecContext(ctx, query, prior, halfLife.Seconds(), window.Seconds())
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "refresh app rankings", "error", err)
+		return 0, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return res.RowsAffected()
+}
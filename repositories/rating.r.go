@@ -0,0 +1,266 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// CreateRating lưu đánh giá, cập nhật điểm trung bình của app và ghi event rating.posted
+// (đánh giá đang chờ kiểm duyệt thì không ghi event)
+func CreateRating(ctx context.Context, rating *models.Rating) error {
+	return withTx(ctx, "create rating", func(tx *sqlx.Tx) error {
+		var publisherId string
+		err := tx.GetContext(ctx, &publisherId, "SELECT publisher_id FROM apps WHERE id = $1 AND deleted_at IS NULL", rating.AppId)
+		if err != nil {
+			return errors.New(configs.GetErrString(configs.ErrorCode_APP_NOT_FOUND))
+		}
+		query := `INSERT INTO ratings (user_id, app_id, comment, stars, status, posted_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, CASE WHEN $5 = 'published' THEN NOW() END, NOW(), NOW())
+			RETURNING *`
+		err = tx.GetContext(ctx, rating, query, rating.UserId, rating.AppId, rating.Comment, rating.Stars, rating.Status)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+				return errors.New(configs.GetErrString(configs.ErrorCode_RATING_ALREADY_EXISTS))
+			}
+			logger.ErrorContext(ctx, "DB error", "op", "create rating", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if err := refreshAppRating(ctx, tx, rating.AppId); err != nil {
+			return err
+		}
+		if rating.Status.String != models.RatingStatusPublished {
+			return nil
+		}
+		payload := map[string]interface{}{"rating": rating, "app_id": rating.AppId, "publisher_id": publisherId}
+		if err := insertEvent(ctx, tx, models.EventRatingPosted, "rating", rating.Id, payload); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "create rating event", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+}
+
+// publishedRating là điều kiện SQL của đánh giá đang hiển thị; bản ghi cũ chưa có status coi như published
+const publishedRating = "COALESCE(status, 'published') = 'published'"
+
+// refreshAppRating tính lại apps.rating từ các đánh giá còn hiệu lực và đang hiển thị
+func refreshAppRating(ctx context.Context, tx sqlx.ExecerContext, appId string) error {
+	query := `UPDATE apps SET rating = COALESCE(
+			(SELECT AVG(stars) FROM ratings WHERE app_id = $1 AND deleted_at IS NULL AND ` + publishedRating + `), 0)
+		WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, appId); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "refresh app rating", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+// GetRatingsByApp trả về đánh giá đang hiển thị của app, mới trước, kèm phản hồi của publisher
+func GetRatingsByApp(ctx context.Context, appId string, limit, offset int) ([]models.Rating, error) {
+	db := configs.DB
+	ratings := []models.Rating{}
+	query := "SELECT * FROM ratings WHERE app_id = $1 AND deleted_at IS NULL AND " + publishedRating + " ORDER BY created_at DESC LIMIT $2 OFFSET $3"
+	err := db.SelectContext(ctx, &ratings, query, appId, limit, offset)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get ratings by app", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return ratings, attachRatingReplies(ctx, ratings)
+}
+
+func GetRatingsByUser(ctx context.Context, userId string) ([]models.Rating, error) {
+	db := configs.DB
+	ratings := []models.Rating{}
+	err := db.SelectContext(ctx, &ratings, "SELECT * FROM ratings WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at", userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get ratings by user", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return ratings, nil
+}
+
+// lockRating khoá đánh giá đang hiển thị của app để cập nhật bộ đếm
+func lockRating(ctx context.Context, tx *sqlx.Tx, appId, ratingId string) (models.Rating, error) {
+	var rating models.Rating
+	query := "SELECT * FROM ratings WHERE id = $1 AND app_id = $2 AND deleted_at IS NULL AND " + publishedRating + " FOR UPDATE"
+	err := tx.GetContext(ctx, &rating, query, ratingId, appId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rating, errors.New(configs.GetErrString(configs.ErrorCode_RATING_NOT_FOUND))
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "lock rating", "error", err)
+		return rating, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return rating, nil
+}
+
+// AddHelpfulVote đánh dấu đánh giá là hữu ích và tăng ratings.helpful_count.
+// Gọi lại khi đã đánh dấu thì không làm gì; không được tự đánh dấu đánh giá của mình.
+func AddHelpfulVote(ctx context.Context, userId, appId, ratingId string) (models.Rating, error) {
+	var rating models.Rating
+	err := withTx(ctx, "add helpful vote", func(tx *sqlx.Tx) error {
+		var err error
+		if rating, err = lockRating(ctx, tx, appId, ratingId); err != nil {
+			return err
+		}
+		if rating.UserId == userId {
+			return errors.New(configs.GetErrString(configs.ErrorCode_RATING_IS_OWN))
+		}
+		res, err := tx.ExecContext(ctx, `INSERT INTO rating_votes (rating_id, user_id, created_at) VALUES ($1, $2, NOW())
+			ON CONFLICT (rating_id, user_id) DO NOTHING`, ratingId, userId)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "add helpful vote", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return nil
+		}
+		err = tx.GetContext(ctx, &rating.HelpfulCount, "UPDATE ratings SET helpful_count = helpful_count + 1 WHERE id = $1 RETURNING helpful_count", ratingId)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "add helpful count", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+	return rating, err
+}
+
+// RemoveHelpfulVote bỏ đánh dấu hữu ích; chưa đánh dấu thì không làm gì
+func RemoveHelpfulVote(ctx context.Context, userId, appId, ratingId string) (models.Rating, error) {
+	var rating models.Rating
+	err := withTx(ctx, "remove helpful vote", func(tx *sqlx.Tx) error {
+		var err error
+		if rating, err = lockRating(ctx, tx, appId, ratingId); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM rating_votes WHERE rating_id = $1 AND user_id = $2", ratingId, userId)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "remove helpful vote", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return nil
+		}
+		err = tx.GetContext(ctx, &rating.HelpfulCount, "UPDATE ratings SET helpful_count = GREATEST(helpful_count - 1, 0) WHERE id = $1 RETURNING helpful_count", ratingId)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "remove helpful count", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+	return rating, err
+}
+
+// ReportRating lưu báo cáo và tăng ratings.report_count. Khi số báo cáo chưa xử lý đạt threshold
+// thì đánh giá chuyển sang pending (ẩn khỏi app, vào hàng đợi kiểm duyệt); trả về true nếu vừa bị ẩn.
+func ReportRating(ctx context.Context, appId string, report *models.RatingReport, threshold int) (bool, error) {
+	hidden := false
+	err := withTx(ctx, "report rating", func(tx *sqlx.Tx) error {
+		rating, err := lockRating(ctx, tx, appId, report.RatingId)
+		if err != nil {
+			return err
+		}
+		if rating.UserId == report.UserId.String {
+			return errors.New(configs.GetErrString(configs.ErrorCode_RATING_IS_OWN))
+		}
+		query := `INSERT INTO rating_reports (rating_id, user_id, reason, details, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+			RETURNING *`
+		err = tx.GetContext(ctx, report, query, report.RatingId, report.UserId, report.Reason, report.Details, models.RatingReportOpen)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+				return errors.New(configs.GetErrString(configs.ErrorCode_RATING_ALREADY_REPORTED))
+			}
+			logger.ErrorContext(ctx, "DB error", "op", "report rating", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		var status string
+		err = tx.GetContext(ctx, &status, `UPDATE ratings SET report_count = report_count + 1,
+				status = CASE WHEN report_count + 1 >= $2 THEN $3 ELSE status END
+			WHERE id = $1 RETURNING status`, report.RatingId, threshold, models.RatingStatusPending)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "report rating count", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if status != models.RatingStatusPending {
+			return nil
+		}
+		hidden = true
+		return refreshAppRating(ctx, tx, appId)
+	})
+	return hidden, err
+}
+
+// GetRatingModerationQueue trả về đánh giá theo status, nhiều báo cáo trước. Với published chỉ
+// trả về đánh giá đang có báo cáo chưa xử lý (chưa tới ngưỡng ẩn tự động).
+func GetRatingModerationQueue(ctx context.Context, status string, limit, offset int) ([]models.RatingModeration, error) {
+	db := configs.DB
+	items := []models.RatingModeration{}
+	query := `SELECT r.*, r.report_count AS open_reports,
+			COALESCE(array_agg(DISTINCT rep.reason) FILTER (WHERE rep.id IS NOT NULL), '{}') AS reasons
+		FROM ratings r
+		LEFT JOIN rating_reports rep ON rep.rating_id = r.id AND rep.status = 'open'
+		WHERE r.deleted_at IS NULL AND COALESCE(r.status, 'published') = $1
+			AND ($1 <> 'published' OR r.report_count > 0)
+		GROUP BY r.id
+		ORDER BY r.report_count DESC, r.created_at
+		LIMIT $2 OFFSET $3`
+	if err := db.SelectContext(ctx, &items, query, status, limit, offset); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get rating moderation queue", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return items, nil
+}
+
+// ModerateRating đặt status của đánh giá theo quyết định của admin, đóng các báo cáo
+// đang mở và tính lại điểm của app; duyệt đánh giá đang chờ thì ghi event rating.posted
+func ModerateRating(ctx context.Context, id, status string) (models.Rating, error) {
+	var rating models.Rating
+	err := withTx(ctx, "moderate rating", func(tx *sqlx.Tx) error {
+		var previous struct {
+			PostedAt    sql.NullTime `db:"posted_at"`
+			PublisherId string       `db:"publisher_id"`
+		}
+		err := tx.GetContext(ctx, &previous, `SELECT r.posted_at, a.publisher_id FROM ratings r JOIN apps a ON a.id = r.app_id
+			WHERE r.id = $1 AND r.deleted_at IS NULL FOR UPDATE OF r`, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_RATING_NOT_FOUND))
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "moderate rating", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		err = tx.GetContext(ctx, &rating, `UPDATE ratings SET status = $2, report_count = 0,
+				posted_at = COALESCE(posted_at, CASE WHEN $2 = 'published' THEN NOW() END)
+			WHERE id = $1 RETURNING *`, id, status)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "moderate rating", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		// rating.posted chỉ ghi lần đầu đánh giá được hiển thị; đánh giá bị ẩn do báo cáo rồi
+		// được duyệt lại đã có posted_at nên không ghi lại
+		if !previous.PostedAt.Valid && rating.PostedAt.Valid {
+			payload := map[string]interface{}{"rating": rating, "app_id": rating.AppId, "publisher_id": previous.PublisherId}
+			if err := insertEvent(ctx, tx, models.EventRatingPosted, "rating", rating.Id, payload); err != nil {
+				logger.ErrorContext(ctx, "DB error", "op", "moderate rating event", "error", err)
+				return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+			}
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE rating_reports SET status = $2, resolved_at = NOW() WHERE rating_id = $1 AND status = $3",
+			id, models.RatingReportResolved, models.RatingReportOpen)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "resolve rating reports", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return refreshAppRating(ctx, tx, rating.AppId)
+	})
+	return rating, err
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// lockRatingForReply kiểm tra đánh giá đang hiển thị thuộc app của publisher và trả về người viết
+func lockRatingForReply(ctx context.Context, tx *sqlx.Tx, publisherId, appId, ratingId string) (string, error) {
+	var target struct {
+		ReviewerId  string `db:"user_id"`
+		PublisherId string `db:"publisher_id"`
+	}
+	query := `SELECT r.user_id, a.publisher_id FROM ratings r JOIN apps a ON a.id = r.app_id
+		WHERE r.id = $1 AND r.app_id = $2 AND r.deleted_at IS NULL AND a.deleted_at IS NULL
+			AND COALESCE(r.status, 'published') = 'published'
+		FOR UPDATE OF r`
+	err := tx.GetContext(ctx, &target, query, ratingId, appId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errors.New(configs.GetErrString(configs.ErrorCode_RATING_NOT_FOUND))
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "lock rating for reply", "error", err)
+		return "", errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if target.PublisherId != publisherId {
+		return "", errors.New(configs.GetErrString(configs.ErrorCode_RATING_REPLY_FORBIDDEN))
+	}
+	return target.ReviewerId, nil
+}
+
+// SaveRatingReply tạo hoặc sửa phản hồi của publisher cho đánh giá và ghi event rating.replied
+// để báo cho người viết đánh giá (sửa mà nội dung không đổi thì không ghi event)
+func SaveRatingReply(ctx context.Context, publisherId, appId, ratingId, body string) (models.RatingReply, error) {
+	var reply models.RatingReply
+	err := withTx(ctx, "save rating reply", func(tx *sqlx.Tx) error {
+		reviewerId, err := lockRatingForReply(ctx, tx, publisherId, appId, ratingId)
+		if err != nil {
+			return err
+		}
+		var previous sql.NullString
+		err = tx.GetContext(ctx, &previous, "SELECT body FROM rating_replies WHERE rating_id = $1", ratingId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logger.ErrorContext(ctx, "DB error", "op", "get rating reply", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		query := `INSERT INTO rating_replies (rating_id, publisher_id, body, created_at, updated_at)
+			VALUES ($1, $2, $3, NOW(), NOW())
+			ON CONFLICT (rating_id) DO UPDATE SET publisher_id = EXCLUDED.publisher_id, body = EXCLUDED.body,
+				updated_at = CASE WHEN rating_replies.body = EXCLUDED.body THEN rating_replies.updated_at ELSE NOW() END
+			RETURNING *`
+		if err := tx.GetContext(ctx, &reply, query, ratingId, publisherId, body); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "save rating reply", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		if previous.Valid && previous.String == body {
+			return nil
+		}
+		payload := models.RatingReplyEvent{
+			RatingId:    ratingId,
+			AppId:       appId,
+			ReviewerId:  reviewerId,
+			PublisherId: publisherId,
+			Reply:       body,
+			Edited:      previous.Valid,
+		}
+		if err := insertEvent(ctx, tx, models.EventRatingReplied, "rating", ratingId, payload); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "save rating reply event", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+	return reply, err
+}
+
+// DeleteRatingReply xoá phản hồi của publisher; chưa có phản hồi thì không làm gì
+func DeleteRatingReply(ctx context.Context, publisherId, appId, ratingId string) error {
+	return withTx(ctx, "delete rating reply", func(tx *sqlx.Tx) error {
+		if _, err := lockRatingForReply(ctx, tx, publisherId, appId, ratingId); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM rating_replies WHERE rating_id = $1", ratingId); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "delete rating reply", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+}
+
+// attachRatingReplies gắn phản hồi của publisher vào các đánh giá
+func attachRatingReplies(ctx context.Context, ratings []models.Rating) error {
+	if len(ratings) == 0 {
+		return nil
+	}
+	ids := make([]string, len(ratings))
+	for i, r := range ratings {
+		ids[i] = r.Id
+	}
+	replies := []models.RatingReply{}
+	err := configs.DB.SelectContext(ctx, &replies, "SELECT * FROM rating_replies WHERE rating_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get rating replies", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	byRating := make(map[string]*models.RatingReply, len(replies))
+	for i := range replies {
+		byRating[replies[i].RatingId] = &replies[i]
+	}
+	for i := range ratings {
+		ratings[i].Reply = byRating[ratings[i].Id]
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// Co-install đếm số user khác đã cài cả hai app. Lượt cài được gộp DISTINCT (user_id, app_id)
+// trong peer_installs trước khi đếm để cài lại nhiều lần không nhân số dòng hay thổi phồng điểm.
+const similarAppsQuery = `WITH target AS (
+		SELECT id, COALESCE(category, '') AS category, COALESCE(tags, '{}') AS tags
+		FROM apps WHERE id = $1 AND deleted_at IS NULL
+	),
+	peer_installs AS (
+		SELECT DISTINCT user_id, app_id FROM app_installs
+		WHERE user_id IN (SELECT user_id FROM app_installs WHERE app_id = $1 AND user_id IS NOT NULL)
+	),
+	co_installs AS (
+		SELECT app_id, COUNT(*) AS users FROM peer_installs WHERE app_id <> $1 GROUP BY app_id
+	),
+	scored AS (
+		SELECT a.id,
+			CASE WHEN t.category <> '' AND a.category = t.category THEN 2 ELSE 0 END
+			+ cardinality(ARRAY(SELECT unnest(a.tags) INTERSECT SELECT unnest(t.tags)))
+			+ 2 * COALESCE(ln(1 + co.users), 0) AS score
+		FROM apps a
+		CROSS JOIN target t
+		LEFT JOIN co_installs co ON co.app_id = a.id
+		WHERE a.id <> t.id AND a.deleted_at IS NULL AND a.status = 'published'
+	)
+	SELECT a.* FROM scored s JOIN apps a ON a.id = s.id
+	WHERE s.score > 0
+	ORDER BY s.score DESC, a.rating DESC
+	LIMIT $2`
+
+// userRecommendationsQuery tính và lưu gợi ý cho user $1, xem RefreshUserRecommendations
+const userRecommendationsQuery = `WITH history AS (
+		SELECT app_id, SUM(weight) AS weight FROM (
+			SELECT DISTINCT app_id, 1.0 AS weight FROM app_installs WHERE user_id = $1
+			UNION ALL
+			SELECT app_id, (stars - 3) * 0.75 FROM ratings WHERE user_id = $1 AND deleted_at IS NULL
+			UNION ALL
+			SELECT app_id, 1.5 FROM favorites WHERE user_id = $1
+		) h
+		GROUP BY app_id
+	),
+	profile AS (
+		SELECT COALESCE(a.category, '') AS category, COALESCE(a.tags, '{}') AS tags, h.weight
+		FROM history h JOIN apps a ON a.id = h.app_id
+	),
+	category_weights AS (
+		SELECT category, SUM(weight) AS weight FROM profile WHERE category <> '' GROUP BY category
+	),
+	tag_weights AS (
+		SELECT tag, SUM(weight) AS weight FROM profile, unnest(tags) AS tag GROUP BY tag
+	),
+	peer_installs AS (
+		SELECT DISTINCT user_id, app_id FROM app_installs
+		WHERE user_id IN (
+			SELECT user_id FROM app_installs
+			WHERE user_id <> $1 AND app_id IN (SELECT app_id FROM app_installs WHERE user_id = $1)
+		)
+	),
+	co_installs AS (
+		SELECT app_id, COUNT(*) AS users FROM peer_installs GROUP BY app_id
+	),
+	scored AS (
+		SELECT a.id,
+			2 * COALESCE(cw.weight, 0)
+			+ COALESCE((SELECT SUM(tw.weight) FROM tag_weights tw WHERE tw.tag = ANY(a.tags)), 0)
+			+ 2 * COALESCE(ln(1 + co.users), 0) AS signal,
+			0.1 * ln(1 + a.downloads) AS popularity
+		FROM apps a
+		LEFT JOIN category_weights cw ON cw.category = a.category
+		LEFT JOIN co_installs co ON co.app_id = a.id
+		WHERE a.deleted_at IS NULL AND a.status = 'published'
+			AND a.id NOT IN (SELECT app_id FROM history)
+			AND a.publisher_id IS DISTINCT FROM $1
+	),
+	top AS (
+		SELECT id, signal + popularity AS score FROM scored WHERE signal > 0
+		ORDER BY score DESC LIMIT $2
+	)
+	INSERT INTO user_recommendations (user_id, app_ids, computed_at)
+	SELECT $1, COALESCE(array_agg(id ORDER BY score DESC), '{}'), NOW() FROM top
+	ON CONFLICT (user_id) DO UPDATE SET app_ids = EXCLUDED.app_ids, computed_at = EXCLUDED.computed_at
+	RETURNING *`
+
+// GetSimilarApps xếp hạng app published theo mức giống app gốc: cùng category,
+// số tag chung và số user đã cài cả hai app (co-install)
+func GetSimilarApps(ctx context.Context, appId string, limit int) ([]models.App, error) {
+	db := configs.DB
+	apps := []models.App{}
+	if err := db.SelectContext(ctx, &apps, similarAppsQuery, appId, limit); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get similar apps", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return apps, nil
+}
+
+// GetUsersDueForRecommendations trả về user có lịch sử cài/đánh giá/yêu thích mà gợi ý chưa
+// được tính, đã cũ hơn ttl hoặc có hoạt động mới sau lần tính trước
+func GetUsersDueForRecommendations(ctx context.Context, ttl time.Duration, limit int) ([]string, error) {
+	db := configs.DB
+	userIds := []string{}
+	query := `SELECT h.user_id FROM (
+			SELECT user_id, MAX(created_at) AS active_at FROM app_installs WHERE user_id IS NOT NULL GROUP BY user_id
+			UNION ALL
+			SELECT user_id, MAX(updated_at) FROM ratings WHERE deleted_at IS NULL GROUP BY user_id
+			UNION ALL
+			SELECT user_id, MAX(created_at) FROM favorites GROUP BY user_id
+		) h
+		JOIN users u ON u.id = h.user_id AND u.purged_at IS NULL
+		LEFT JOIN user_recommendations r ON r.user_id = h.user_id
+		GROUP BY h.user_id, r.computed_at
+		HAVING r.computed_at IS NULL OR r.computed_at < NOW() - make_interval(secs => $1) OR MAX(h.active_at) > r.computed_at
+		ORDER BY r.computed_at NULLS FIRST
+		LIMIT $2`
+	if err := db.SelectContext(ctx, &userIds, query, ttl.Seconds(), limit); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get users due for recommendations", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return userIds, nil
+}
+
+// RefreshUserRecommendations tính lại gợi ý cho user từ lịch sử cài và đánh giá:
+// mỗi app trong lịch sử có trọng số (cài +1, đánh giá (sao - 3) * 0.75, yêu thích +1.5), cộng dồn thành
+// trọng số category/tag; app ứng viên được cộng điểm theo category, tag, co-install
+// với user khác và một phần nhỏ theo lượt tải. App đã cài/đánh giá và app của chính
+// user bị loại.
+func RefreshUserRecommendations(ctx context.Context, userId string, limit int) (models.UserRecommendations, error) {
+	db := configs.DB
+	var rec models.UserRecommendations
+	if err := db.GetContext(ctx, &rec, userRecommendationsQuery, userId, limit); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "refresh user recommendations", "error", err)
+		return rec, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return rec, nil
+}
+
+// GetUserRecommendations trả về gợi ý đã tính; found = false nếu chưa từng tính cho user
+func GetUserRecommendations(ctx context.Context, userId string) (rec models.UserRecommendations, found bool, err error) {
+	db := configs.DB
+	err = db.GetContext(ctx, &rec, "SELECT * FROM user_recommendations WHERE user_id = $1", userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rec, false, nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get user recommendations", "error", err)
+		return rec, false, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return rec, true, nil
+}
+
+// GetPublishedAppsByIds trả về app published theo đúng thứ tự ids, bỏ qua app không còn hiển thị
+func GetPublishedAppsByIds(ctx context.Context, ids []string) ([]models.App, error) {
+	db := configs.DB
+	apps := []models.App{}
+	query := `SELECT a.* FROM unnest($1::uuid[]) WITH ORDINALITY AS u(id, ord)
+		JOIN apps a ON a.id = u.id
+		WHERE a.deleted_at IS NULL AND a.status = 'published'
+		ORDER BY u.ord`
+	if err := db.SelectContext(ctx, &apps, query, pq.StringArray(ids)); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get published apps by ids", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return apps, nil
+}
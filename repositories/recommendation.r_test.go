@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Co-install phải đếm trên lượt cài đã gộp theo (user_id, app_id): self-join trực tiếp trên
+// app_installs làm user cài lại nhiều lần nhân số dòng của join
+func TestCoInstallQueriesDedupeInstalls(t *testing.T) {
+	rawJoin := regexp.MustCompile(`JOIN\s+app_installs`)
+	queries := map[string]string{
+		"similar apps":         similarAppsQuery,
+		"user recommendations": userRecommendationsQuery,
+	}
+	for name, query := range queries {
+		if rawJoin.MatchString(query) {
+			t.Errorf("%s: joins raw app_installs", name)
+		}
+		if !strings.Contains(query, "peer_installs AS (\n\t\tSELECT DISTINCT user_id, app_id FROM app_installs") {
+			t.Errorf("%s: installs not deduped by (user_id, app_id) before counting", name)
+		}
+		if !strings.Contains(query, "COUNT(*) AS users FROM peer_installs") {
+			t.Errorf("%s: co_installs does not count the deduped installs", name)
+		}
+	}
+	if !strings.Contains(userRecommendationsQuery, "SELECT DISTINCT app_id, 1.0 AS weight FROM app_installs") {
+		t.Error("user recommendations: repeated installs add history weight more than once")
+	}
+}
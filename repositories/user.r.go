@@ -1,16 +1,21 @@
 package repositories
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"time"
 
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 	"waheim.api/configs"
 	"waheim.api/models"
 )
 
-func SignIn(request map[string]string) (string, error) {
+// SignIn kiểm tra mật khẩu, cấp JWT và ghi lại phiên đăng nhập kèm ip/user agent
+func SignIn(ctx context.Context, request map[string]string, ip, userAgent string) (string, error) {
 	db := configs.DB
 	var user models.User
 
@@ -20,14 +25,14 @@ func SignIn(request map[string]string) (string, error) {
 		return "", errors.New(configs.GetErrString(configs.ErrorCode_SIGN_IN_MISSING_FIELDS))
 	}
 
-	query := `SELECT * FROM users WHERE username ILIKE $1 OR email ILIKE $1 OR phone ILIKE $1 LIMIT 1`
-	err := db.Get(&user, query, waheimId)
+	query := `SELECT * FROM users WHERE (username ILIKE $1 OR email ILIKE $1 OR phone ILIKE $1) AND deleted_at IS NULL LIMIT 1`
+	err := db.GetContext(ctx, &user, query, waheimId)
 	if err != nil {
-		log.Printf("SignIn DB error: %v, waheim_id: %s", err, waheimId)
+		logger.WarnContext(ctx, "sign in lookup failed", "error", err)
 		return "", errors.New(configs.GetErrString(configs.ErrorCode_AUTH_FAILED))
 	}
 
-	if !user.IsActive {
+	if !user.IsActive && !user.SelfDeactivated() {
 		return "", errors.New(configs.GetErrString(configs.ErrorCode_USER_NOT_ACTIVE))
 	}
 
@@ -35,33 +40,43 @@ func SignIn(request map[string]string) (string, error) {
 		return "", errors.New(configs.GetErrString(configs.ErrorCode_AUTH_FAILED))
 	}
 
+	// Tài khoản user tự tắt được mở lại khi đăng nhập lại
+	if user.SelfDeactivated() {
+		if err := SetUserActive(ctx, user.Id, true, ""); err != nil {
+			return "", err
+		}
+	}
+
 	tokenString, err := configs.GenerateJwt(user.Id, user.Role)
 	if err != nil {
 		return "", errors.New(configs.GetErrString(configs.ErrorCode_FAILED_TO_GENERATE_TOKEN))
 	}
+	if err := CreateUserSession(ctx, user.Id, ip, userAgent, time.Now().Add(configs.JwtTTL)); err != nil {
+		return "", err
+	}
 
 	return tokenString, nil
 }
 
-func AuthMe(tokenString string) (models.User, error) {
+func AuthMe(ctx context.Context, tokenString string) (models.User, error) {
 	claims, err := configs.ValidateJwt(tokenString)
 	if err != nil {
 		return models.User{}, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_TOKEN))
 	}
-	userId, ok := claims["user_id"].(string)
+	userId, ok := claims["sub"].(string)
 	if !ok {
 		return models.User{}, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_USER_ID_IN_TOKEN))
 	}
 	db := configs.DB
 	var user models.User
-	err = db.Get(&user, "SELECT * FROM users WHERE id = $1 LIMIT 1", userId)
+	err = db.GetContext(ctx, &user, "SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL LIMIT 1", userId)
 	if err != nil {
 		return user, errors.New(configs.GetErrString(configs.ErrorCode_USER_NOT_FOUND))
 	}
 	return user, nil
 }
 
-func SignUp(request map[string]string) error {
+func SignUp(ctx context.Context, request map[string]string) error {
 	db := configs.DB
 
 	username := request["username"]
@@ -76,14 +91,14 @@ func SignUp(request map[string]string) error {
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Password hash error: %v", err)
+		logger.ErrorContext(ctx, "password hash failed", "error", err)
 		return errors.New(configs.GetErrString(configs.ErrorCode_FAILED_TO_HASH_PASSWORD))
 	}
 
 	var exists int
-	err = db.Get(&exists, "SELECT COUNT(*) FROM users WHERE username=$1 OR email=$2 OR phone=$3", username, email, phone)
+	err = db.GetContext(ctx, &exists, "SELECT COUNT(*) FROM users WHERE username=$1 OR email=$2 OR phone=$3", username, email, phone)
 	if err != nil {
-		log.Printf("DB error (check exists): %v", err)
+		logger.ErrorContext(ctx, "DB error", "op", "check exists", "error", err)
 		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
 	}
 	if exists > 0 {
@@ -91,7 +106,7 @@ func SignUp(request map[string]string) error {
 	}
 
 	var user models.User
-	err = db.QueryRowx(
+	err = db.QueryRowxContext(ctx,
 		`INSERT INTO users (username, email, phone, password, address, is_active, role, created_at, updated_at)
 		 VALUES ($1, $2, $3, $4, $5, true, 'user', NOW(), NOW())
 		 RETURNING id, username, password, email, phone, address, created_at, updated_at, deleted_at, is_active, role, avatar, first_name, last_name, date_of_birth, gender, status`,
@@ -116,14 +131,14 @@ func SignUp(request map[string]string) error {
 		&user.Status,
 	)
 	if err != nil {
-		log.Printf("DB error (insert): %v", err)
+		logger.ErrorContext(ctx, "DB error", "op", "insert", "error", err)
 		return errors.New(configs.GetErrString(configs.ErrorCode_FAILED_TO_INSERT_USER))
 	}
 
 	return nil
 }
 
-func GetAllUsers(filters map[string]string, limit, offset int) ([]models.User, error) {
+func GetAllUsers(ctx context.Context, filters map[string]string, limit, offset int) ([]models.User, error) {
 	db := configs.DB
 	var users []models.User
 	query := "SELECT * FROM users WHERE deleted_at IS NULL"
@@ -169,27 +184,27 @@ func GetAllUsers(filters map[string]string, limit, offset int) ([]models.User, e
 		idx++
 	}
 
-	err := db.Select(&users, query, args...)
+	err := db.SelectContext(ctx, &users, query, args...)
 	if err != nil {
-		log.Printf("DB error (get all users): %v", err)
+		logger.ErrorContext(ctx, "DB error", "op", "get all users", "error", err)
 		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
 	}
 	return users, nil
 }
 
 // Lấy user theo id
-func GetUserById(id string) (models.User, error) {
+func GetUserById(ctx context.Context, id string) (models.User, error) {
 	db := configs.DB
 	var user models.User
-	err := db.Get(&user, "SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL", id)
+	err := db.GetContext(ctx, &user, "SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL", id)
 	if err != nil {
-		log.Printf("DB error (get user by id): %v", err)
+		logger.ErrorContext(ctx, "DB error", "op", "get user by id", "error", err)
 		return user, errors.New(configs.GetErrString(configs.ErrorCode_USER_NOT_FOUND))
 	}
 	return user, nil
 }
 
-func UpdateUser(id string, updates map[string]interface{}) error {
+func UpdateUser(ctx context.Context, id string, updates map[string]interface{}) error {
 	db := configs.DB
 	if len(updates) == 0 {
 		return nil
@@ -209,9 +224,9 @@ func UpdateUser(id string, updates map[string]interface{}) error {
 	setClause += ", updated_at = NOW()"
 	args = append(args, id)
 	query := fmt.Sprintf("UPDATE users SET %s WHERE id = $%d AND deleted_at IS NULL", setClause, idx)
-	res, err := db.Exec(query, args...)
+	res, err := db.ExecContext(ctx, query, args...)
 	if err != nil {
-		log.Printf("DB error (update user): %v", err)
+		logger.ErrorContext(ctx, "DB error", "op", "update user", "error", err)
 		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
 	}
 	rows, _ := res.RowsAffected()
@@ -221,12 +236,33 @@ func UpdateUser(id string, updates map[string]interface{}) error {
 	return nil
 }
 
-func DeleteUser(id string) error {
-	db := configs.DB
+func DeleteUser(ctx context.Context, id string) error {
 	query := "UPDATE users SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL"
-	res, err := db.Exec(query, id)
+	return withTx(ctx, "delete user", func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, query, id)
+		if err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "delete user", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		rows, _ := res.RowsAffected()
+		if rows == 0 {
+			return errors.New(configs.GetErrString(configs.ErrorCode_USER_NOT_FOUND))
+		}
+		if err := insertEvent(ctx, tx, models.EventUserDeleted, "user", id, map[string]string{"user_id": id}); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "delete user event", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+		return nil
+	})
+}
+
+// SetUserActive bật/tắt tài khoản và ghi lý do vào status (rỗng thì xoá status)
+func SetUserActive(ctx context.Context, id string, active bool, status string) error {
+	db := configs.DB
+	query := "UPDATE users SET is_active = $1, status = NULLIF($3, ''), updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL"
+	res, err := db.ExecContext(ctx, query, active, id, status)
 	if err != nil {
-		log.Printf("DB error (delete user): %v", err)
+		logger.ErrorContext(ctx, "DB error", "op", "set user active", "error", err)
 		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
 	}
 	rows, _ := res.RowsAffected()
@@ -235,3 +271,151 @@ func DeleteUser(id string) error {
 	}
 	return nil
 }
+
+// IsUserActive cho biết user còn dùng được (đang active, chưa xoá, chưa purge);
+// user không tồn tại trả về false
+func IsUserActive(ctx context.Context, id string) (bool, error) {
+	db := configs.DB
+	var active bool
+	query := "SELECT COALESCE(is_active, true) FROM users WHERE id = $1 AND deleted_at IS NULL AND purged_at IS NULL"
+	err := db.GetContext(ctx, &active, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "check user active", "error", err)
+		return false, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return active, nil
+}
+
+// RestoreUser huỷ soft delete nếu user vẫn còn trong thời gian gia hạn
+func RestoreUser(ctx context.Context, id string, grace time.Duration) error {
+	db := configs.DB
+	query := `UPDATE users SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL
+		AND deleted_at::timestamptz > NOW() - make_interval(secs => $2)`
+	res, err := db.ExecContext(ctx, query, id, grace.Seconds())
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "restore user", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return errors.New(configs.GetErrString(configs.ErrorCode_USER_NOT_RESTORABLE))
+	}
+	return nil
+}
+
+// GetUserIdsDueForPurge trả về các user đã soft delete quá thời gian gia hạn, bỏ qua các id trong skip
+func GetUserIdsDueForPurge(ctx context.Context, grace time.Duration, limit int, skip []string) ([]string, error) {
+	db := configs.DB
+	ids := []string{}
+	if skip == nil {
+		skip = []string{}
+	}
+	query := `SELECT id FROM users
+		WHERE deleted_at IS NOT NULL AND purged_at IS NULL
+		AND deleted_at::timestamptz <= NOW() - make_interval(secs => $1)
+		AND NOT (id::text = ANY($3))
+		ORDER BY deleted_at LIMIT $2`
+	err := db.SelectContext(ctx, &ids, query, grace.Seconds(), limit, pq.Array(skip))
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get users due for purge", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return ids, nil
+}
+
+// PurgeUser xoá PII của user và xử lý app/rating của họ theo policy, trong một transaction.
+// appPolicy: "delete" (soft delete app) hoặc "keep"; ratingPolicy: "delete" hoặc "anonymize".
+func PurgeUser(ctx context.Context, id, appPolicy, ratingPolicy string) error {
+	db := configs.DB
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user begin", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	defer tx.Rollback()
+
+	// Đổi username/email sang giá trị không trùng để giải phóng UNIQUE constraint
+	_, err = tx.ExecContext(ctx, `UPDATE users SET
+			username = 'deleted-' || id, email = id || '@deleted.invalid',
+			password = '', phone = '', address = '', avatar = NULL,
+			first_name = NULL, last_name = NULL, date_of_birth = NULL, gender = NULL,
+			is_active = false, status = 'purged', purged_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND purged_at IS NULL`, id)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if _, err = tx.ExecContext(ctx, "UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", id); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user api keys", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if appPolicy == "delete" {
+		if _, err = tx.ExecContext(ctx, "UPDATE apps SET deleted_at = NOW() WHERE publisher_id = $1 AND deleted_at IS NULL", id); err != nil {
+			logger.ErrorContext(ctx, "DB error", "op", "purge user apps", "error", err)
+			return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+		}
+	}
+	// Lượt cài giữ lại cho thống kê nhưng không còn gắn với user
+	if _, err = tx.ExecContext(ctx, "UPDATE app_installs SET user_id = NULL, installer = 'anon:' || id WHERE user_id = $1", id); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user installs", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE apps SET favorite_count = GREATEST(favorite_count - 1, 0)
+		WHERE id IN (SELECT app_id FROM favorites WHERE user_id = $1)`, id)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, "DELETE FROM favorites WHERE user_id = $1", id)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user favorites", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE ratings SET helpful_count = GREATEST(helpful_count - 1, 0)
+		WHERE id IN (SELECT rating_id FROM rating_votes WHERE user_id = $1)`, id)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, "DELETE FROM rating_votes WHERE user_id = $1", id)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user rating votes", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	// Báo cáo giữ lại cho hàng đợi kiểm duyệt nhưng không còn gắn với user
+	if _, err = tx.ExecContext(ctx, "UPDATE rating_reports SET user_id = NULL WHERE user_id = $1", id); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user rating reports", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM notifications WHERE user_id = $1", id)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, "DELETE FROM notification_preferences WHERE user_id = $1", id)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user notifications", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM user_sessions WHERE user_id = $1", id); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user sessions", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM user_recommendations WHERE user_id = $1", id); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user recommendations", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	switch ratingPolicy {
+	case "delete":
+		_, err = tx.ExecContext(ctx, "UPDATE ratings SET deleted_at = NOW() WHERE user_id = $1 AND deleted_at IS NULL", id)
+	case "anonymize":
+		_, err = tx.ExecContext(ctx, "UPDATE ratings SET comment = NULL, updated_at = NOW() WHERE user_id = $1", id)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user ratings", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if err = tx.Commit(); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "purge user commit", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// CreateUserSession ghi lại một lần đăng nhập; ip và userAgent rỗng được lưu là NULL
+func CreateUserSession(ctx context.Context, userId, ip, userAgent string, expiresAt time.Time) error {
+	db := configs.DB
+	query := `INSERT INTO user_sessions (user_id, ip, user_agent, created_at, expires_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NOW(), $4)`
+	if _, err := db.ExecContext(ctx, query, userId, ip, userAgent, expiresAt); err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "create user session", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func GetUserSessionsByUser(ctx context.Context, userId string) ([]models.UserSession, error) {
+	db := configs.DB
+	sessions := []models.UserSession{}
+	err := db.SelectContext(ctx, &sessions, "SELECT * FROM user_sessions WHERE user_id = $1 ORDER BY created_at DESC", userId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get user sessions", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return sessions, nil
+}
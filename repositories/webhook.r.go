@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+func CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	db := configs.DB
+	query := `INSERT INTO webhook_subscriptions (publisher_id, url, secret, event_types, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		RETURNING *`
+	err := db.GetContext(ctx, sub, query, sub.PublisherId, sub.Url, sub.Secret, sub.EventTypes, sub.Active)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "create webhook subscription", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func GetWebhookSubscriptions(ctx context.Context, publisherId string) ([]models.WebhookSubscription, error) {
+	db := configs.DB
+	subs := []models.WebhookSubscription{}
+	query := "SELECT * FROM webhook_subscriptions WHERE publisher_id = $1 AND deleted_at IS NULL ORDER BY created_at"
+	err := db.SelectContext(ctx, &subs, query, publisherId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get webhook subscriptions", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return subs, nil
+}
+
+func GetWebhookSubscription(ctx context.Context, id, publisherId string) (models.WebhookSubscription, error) {
+	db := configs.DB
+	var sub models.WebhookSubscription
+	query := "SELECT * FROM webhook_subscriptions WHERE id = $1 AND publisher_id = $2 AND deleted_at IS NULL"
+	err := db.GetContext(ctx, &sub, query, id, publisherId)
+	if err != nil {
+		return sub, errors.New(configs.GetErrString(configs.ErrorCode_WEBHOOK_NOT_FOUND))
+	}
+	return sub, nil
+}
+
+func UpdateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	db := configs.DB
+	query := `UPDATE webhook_subscriptions SET url = $1, event_types = $2, active = $3, updated_at = NOW()
+		WHERE id = $4 AND publisher_id = $5 AND deleted_at IS NULL
+		RETURNING *`
+	err := db.GetContext(ctx, sub, query, sub.Url, sub.EventTypes, sub.Active, sub.Id, sub.PublisherId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New(configs.GetErrString(configs.ErrorCode_WEBHOOK_NOT_FOUND))
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "update webhook subscription", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func DeleteWebhookSubscription(ctx context.Context, id, publisherId string) error {
+	db := configs.DB
+	query := "UPDATE webhook_subscriptions SET deleted_at = NOW(), active = false WHERE id = $1 AND publisher_id = $2 AND deleted_at IS NULL"
+	res, err := db.ExecContext(ctx, query, id, publisherId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "delete webhook subscription", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return errors.New(configs.GetErrString(configs.ErrorCode_WEBHOOK_NOT_FOUND))
+	}
+	return nil
+}
+
+// EnqueueWebhookDeliveries tạo delivery cho mọi subscription đang active của publisher
+// có đăng ký loại event này (event_types rỗng nghĩa là nhận tất cả).
+// Trùng event_id sẽ bị bỏ qua nên gọi lại nhiều lần vẫn an toàn.
+func EnqueueWebhookDeliveries(ctx context.Context, publisherId string, event models.Event) error {
+	db := configs.DB
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+		SELECT id, $1, $2, $3, 'pending', NOW(), NOW() FROM webhook_subscriptions
+		WHERE publisher_id = $4 AND active AND deleted_at IS NULL
+		AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`
+	_, err := db.ExecContext(ctx, query, event.Id, event.Type, event.Payload, publisherId)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "enqueue webhook deliveries", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+// CreateWebhookDelivery tạo delivery đã được nhận sẵn trong lease, như ClaimWebhookDeliveries,
+// để người tạo gửi ngay mà worker không gửi trùng; lease hết mà chưa lưu kết quả thì worker gửi lại
+func CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery, lease time.Duration) error {
+	db := configs.DB
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, 'pending', NOW() + make_interval(secs => $5), NOW())
+		RETURNING *`
+	err := db.GetContext(ctx, delivery, query, delivery.SubscriptionId, delivery.EventId, delivery.EventType, delivery.Payload, lease.Seconds())
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "create webhook delivery", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+// WebhookJob là delivery đến hạn gửi kèm subscription của nó
+type WebhookJob struct {
+	Delivery     models.WebhookDelivery
+	Subscription models.WebhookSubscription
+}
+
+func ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookJob, error) {
+	db := configs.DB
+	deliveries := []models.WebhookDelivery{}
+	query := `UPDATE webhook_deliveries SET next_attempt_at = NOW() + make_interval(secs => $1)
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at FOR UPDATE SKIP LOCKED LIMIT $2
+		)
+		RETURNING *`
+	err := db.SelectContext(ctx, &deliveries, query, lease.Seconds(), limit)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "claim webhook deliveries", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.SubscriptionId)
+	}
+	subs := []models.WebhookSubscription{}
+	err = db.SelectContext(ctx, &subs, "SELECT * FROM webhook_subscriptions WHERE id = ANY($1)", pq.StringArray(ids))
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "claim webhook deliveries", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	byId := map[string]models.WebhookSubscription{}
+	for _, s := range subs {
+		byId[s.Id] = s
+	}
+	jobs := make([]WebhookJob, 0, len(deliveries))
+	for _, d := range deliveries {
+		jobs = append(jobs, WebhookJob{Delivery: d, Subscription: byId[d.SubscriptionId]})
+	}
+	return jobs, nil
+}
+
+// SaveWebhookAttempt ghi kết quả một lần gửi vào delivery log
+func SaveWebhookAttempt(ctx context.Context, d *models.WebhookDelivery) error {
+	db := configs.DB
+	query := `UPDATE webhook_deliveries SET status = $1, attempts = $2, response_code = $3, response_body = $4,
+			error = $5, duration_ms = $6, next_attempt_at = $7, delivered_at = $8
+		WHERE id = $9`
+	_, err := db.ExecContext(ctx, query, d.Status, d.Attempts, d.ResponseCode, d.ResponseBody,
+		d.Error, d.DurationMs, d.NextAttemptAt, d.DeliveredAt, d.Id)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "save webhook attempt", "error", err)
+		return errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return nil
+}
+
+func GetWebhookDeliveries(ctx context.Context, subscriptionId string, limit, offset int) ([]models.WebhookDelivery, error) {
+	db := configs.DB
+	deliveries := []models.WebhookDelivery{}
+	query := "SELECT * FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3"
+	err := db.SelectContext(ctx, &deliveries, query, subscriptionId, limit, offset)
+	if err != nil {
+		logger.ErrorContext(ctx, "DB error", "op", "get webhook deliveries", "error", err)
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_DATABASE_ERROR))
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+// AccountService quản lý vòng đời tài khoản: deactivate, soft delete, restore và purge.
+// Policy đọc từ env:
+//   - ACCOUNT_PURGE_GRACE_DAYS: số ngày giữ tài khoản đã xoá trước khi purge (mặc định 30)
+//   - ACCOUNT_PURGE_APPS: "delete" (mặc định) hoặc "keep"
+//   - ACCOUNT_PURGE_RATINGS: "anonymize" (mặc định) hoặc "delete"
+type AccountService struct {
+	Grace        time.Duration
+	AppPolicy    string
+	RatingPolicy string
+
+	// Truy cập DB, thay được trong test
+	setActive   func(ctx context.Context, id string, active bool, status string) error
+	isActive    func(ctx context.Context, id string) (bool, error)
+	dueForPurge func(ctx context.Context, grace time.Duration, limit int, skip []string) ([]string, error)
+	purgeUser   func(ctx context.Context, id, appPolicy, ratingPolicy string) error
+}
+
+func NewAccountService() *AccountService {
+	s := &AccountService{
+		Grace:        30 * 24 * time.Hour,
+		AppPolicy:    "delete",
+		RatingPolicy: "anonymize",
+		setActive:    repositories.SetUserActive,
+		isActive:     repositories.IsUserActive,
+		dueForPurge:  repositories.GetUserIdsDueForPurge,
+		purgeUser:    repositories.PurgeUser,
+	}
+	if days, err := strconv.Atoi(os.Getenv("ACCOUNT_PURGE_GRACE_DAYS")); err == nil && days >= 0 {
+		s.Grace = time.Duration(days) * 24 * time.Hour
+	}
+	if p := os.Getenv("ACCOUNT_PURGE_APPS"); p == "delete" || p == "keep" {
+		s.AppPolicy = p
+	}
+	if p := os.Getenv("ACCOUNT_PURGE_RATINGS"); p == "delete" || p == "anonymize" {
+		s.RatingPolicy = p
+	}
+	return s
+}
+
+// Deactivate tắt tài khoản. User tự tắt thì đăng nhập lại sẽ mở lại; admin tắt thì
+// chỉ admin mở lại được qua Activate
+func (s *AccountService) Deactivate(ctx context.Context, id string, byAdmin bool) error {
+	status := models.UserStatusDeactivated
+	if byAdmin {
+		status = models.UserStatusSuspended
+	}
+	return s.setActive(ctx, id, false, status)
+}
+
+func (s *AccountService) Activate(ctx context.Context, id string) error {
+	return s.setActive(ctx, id, true, "")
+}
+
+// IsActive cho biết JWT của user còn dùng được: tài khoản bị tắt, xoá hoặc purge thì không
+func (s *AccountService) IsActive(ctx context.Context, id string) (bool, error) {
+	return s.isActive(ctx, id)
+}
+
+func (s *AccountService) Delete(ctx context.Context, id string) error {
+	return repositories.DeleteUser(ctx, id)
+}
+
+func (s *AccountService) Restore(ctx context.Context, id string) error {
+	return repositories.RestoreUser(ctx, id, s.Grace)
+}
+
+// PurgeExpired purge các tài khoản đã hết thời gian gia hạn, dùng làm job định kỳ.
+// Tài khoản purge lỗi được log và bỏ qua trong lần chạy này để không chặn các tài khoản khác.
+func (s *AccountService) PurgeExpired(ctx context.Context) error {
+	failed := []string{}
+	for ctx.Err() == nil {
+		ids, err := s.dueForPurge(ctx, s.Grace, 100, failed)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		for _, id := range ids {
+			if err := s.purgeUser(ctx, id, s.AppPolicy, s.RatingPolicy); err != nil {
+				logger.ErrorContext(ctx, "purge user failed", "user_id", id, "error", err)
+				failed = append(failed, id)
+				continue
+			}
+			logger.InfoContext(ctx, "purged user", "user_id", id)
+		}
+	}
+	return ctx.Err()
+}
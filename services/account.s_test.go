@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"waheim.api/models"
+)
+
+func TestAccountDeactivateStatus(t *testing.T) {
+	users := map[string]models.User{}
+	s := NewAccountService()
+	s.setActive = func(ctx context.Context, id string, active bool, status string) error {
+		users[id] = models.User{Id: id, IsActive: active, Status: sql.NullString{String: status, Valid: status != ""}}
+		return nil
+	}
+
+	s.Deactivate(context.Background(), "self", false)
+	s.Deactivate(context.Background(), "banned", true)
+	if !users["self"].SelfDeactivated() {
+		t.Errorf("self deactivation not reactivatable by sign in: %+v", users["self"])
+	}
+	if users["banned"].SelfDeactivated() || users["banned"].IsActive {
+		t.Errorf("admin suspension reactivatable by sign in: %+v", users["banned"])
+	}
+
+	s.Activate(context.Background(), "banned")
+	if u := users["banned"]; !u.IsActive || u.Status.Valid {
+		t.Errorf("activate: got %+v", u)
+	}
+}
+
+func TestAccountPurgeExpiredSkipsFailures(t *testing.T) {
+	due := []string{"bad", "a", "b", "c"}
+	purged := map[string]bool{}
+	s := NewAccountService()
+	s.dueForPurge = func(ctx context.Context, grace time.Duration, limit int, skip []string) ([]string, error) {
+		ids := []string{}
+		for _, id := range due {
+			if purged[id] || containsString(skip, id) || len(ids) == 2 {
+				continue
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+	s.purgeUser = func(ctx context.Context, id, appPolicy, ratingPolicy string) error {
+		if id == "bad" {
+			return errors.New("boom")
+		}
+		purged[id] = true
+		return nil
+	}
+
+	if err := s.PurgeExpired(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if !purged[id] {
+			t.Errorf("%s not purged after earlier failure", id)
+		}
+	}
+}
+
+func TestAccountPurgeExpiredListError(t *testing.T) {
+	s := NewAccountService()
+	s.dueForPurge = func(ctx context.Context, grace time.Duration, limit int, skip []string) ([]string, error) {
+		return nil, errors.New("db down")
+	}
+	if err := s.PurgeExpired(context.Background()); err == nil {
+		t.Fatal("expected list error")
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+// Định dạng key: whk_<prefix>_<secret>. Chỉ hash của secret được lưu lại.
+const ApiKeyPrefix = "whk_"
+
+var ApiKeyScopes = []string{"app:read", "app:write", "user:read", "user:write"}
+
+type ApiKeyService struct{}
+
+func NewApiKeyService() *ApiKeyService {
+	return &ApiKeyService{}
+}
+
+func (s *ApiKeyService) CreateApiKey(ctx context.Context, userId, name string, scopes []string, expiresAt *time.Time) (models.ApiKey, error) {
+	var key models.ApiKey
+	if name == "" || len(scopes) == 0 {
+		return key, errors.New(configs.GetErrString(configs.ErrorCode_MISSING_REQUIRED_FIELDS))
+	}
+	for _, scope := range scopes {
+		if !containsString(ApiKeyScopes, scope) {
+			return key, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_API_KEY_SCOPE))
+		}
+	}
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return key, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_REQUEST))
+	}
+
+	prefix := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return key, errors.New(configs.GetErrString(configs.ErrorCode_FAILED_TO_GENERATE_KEY))
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return key, errors.New(configs.GetErrString(configs.ErrorCode_FAILED_TO_GENERATE_KEY))
+	}
+	secretStr := base64.RawURLEncoding.EncodeToString(secret)
+
+	key.UserId = userId
+	key.Name = name
+	key.Prefix = hex.EncodeToString(prefix)
+	key.SecretHash = hashApiKeySecret(secretStr)
+	key.Scopes = scopes
+	if expiresAt != nil {
+		key.ExpiresAt = sql.NullTime{Time: *expiresAt, Valid: true}
+	}
+	if err := repositories.CreateApiKey(ctx, &key); err != nil {
+		return key, err
+	}
+	// Cảnh báo bảo mật: key lộ ra ngoài thì user biết để thu hồi
+	err := notificationService.Notify(ctx, userId, models.NotificationSecurity, "New API key created",
+		fmt.Sprintf("API key %q (%s) was created for your account. Revoke it if this was not you.", name, strings.Join(scopes, ", ")),
+		map[string]interface{}{"api_key_id": key.Id, "prefix": key.Prefix})
+	if err != nil {
+		logger.WarnContext(ctx, "api key notification failed", "api_key_id", key.Id, "error", err)
+	}
+	key.Key = ApiKeyPrefix + key.Prefix + "_" + secretStr
+	return key, nil
+}
+
+func (s *ApiKeyService) GetApiKeys(ctx context.Context, userId string) ([]models.ApiKey, error) {
+	return repositories.GetApiKeysByUser(ctx, userId)
+}
+
+func (s *ApiKeyService) RevokeApiKey(ctx context.Context, id, userId string) error {
+	return repositories.RevokeApiKey(ctx, id, userId)
+}
+
+// Authenticate kiểm tra key thô và trả về key kèm role của user sở hữu
+func (s *ApiKeyService) Authenticate(ctx context.Context, raw string) (models.ApiKeyOwner, error) {
+	invalid := errors.New(configs.GetErrString(configs.ErrorCode_INVALID_API_KEY))
+	rest, ok := strings.CutPrefix(raw, ApiKeyPrefix)
+	if !ok {
+		return models.ApiKeyOwner{}, invalid
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return models.ApiKeyOwner{}, invalid
+	}
+	key, err := repositories.GetApiKeyOwnerByPrefix(ctx, prefix)
+	if err != nil {
+		return key, invalid
+	}
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashApiKeySecret(secret))) != 1 {
+		return models.ApiKeyOwner{}, invalid
+	}
+	if key.ExpiresAt.Valid && key.ExpiresAt.Time.Before(time.Now()) {
+		return models.ApiKeyOwner{}, invalid
+	}
+	if !key.IsActive || key.UserDeletedAt.Valid {
+		return models.ApiKeyOwner{}, errors.New(configs.GetErrString(configs.ErrorCode_USER_NOT_ACTIVE))
+	}
+	_ = repositories.TouchApiKey(ctx, key.Id)
+	return key, nil
+}
+
+func hashApiKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"waheim.api/models"
+)
+
+// InspectApk đọc package name, version, SDK, permission từ AndroidManifest.xml (binary XML)
+// và fingerprint SHA-256 của chứng chỉ ký (APK Signature Scheme v3/v2, fallback v1).
+func InspectApk(r io.ReaderAt, size int64) (models.ApkInfo, error) {
+	var info models.ApkInfo
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return info, fmt.Errorf("apk is not a valid zip: %w", err)
+	}
+	var manifest, v1Sig *zip.File
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "AndroidManifest.xml":
+			manifest = f
+		case strings.HasPrefix(f.Name, "META-INF/") && v1Sig == nil:
+			switch strings.ToUpper(path.Ext(f.Name)) {
+			case ".RSA", ".DSA", ".EC":
+				v1Sig = f
+			}
+		}
+	}
+	if manifest == nil {
+		return info, errors.New("apk has no AndroidManifest.xml")
+	}
+	data, err := readZipFile(manifest, 4<<20)
+	if err != nil {
+		return info, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := parseBinaryManifest(data, &info); err != nil {
+		return info, fmt.Errorf("parsing manifest: %w", err)
+	}
+	if info.PackageName == "" {
+		return info, errors.New("manifest has no package name")
+	}
+
+	cert, err := apkSigningCert(r, size)
+	if errors.Is(err, errNoSigningBlock) && v1Sig != nil {
+		var sig []byte
+		if sig, err = readZipFile(v1Sig, 1<<20); err == nil {
+			cert, err = pkcs7FirstCert(sig)
+		}
+	}
+	if err != nil {
+		return info, fmt.Errorf("reading signing certificate: %w", err)
+	}
+	sum := sha256.Sum256(cert)
+	info.CertSha256 = hex.EncodeToString(sum[:])
+	return info, nil
+}
+
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%s larger than %d bytes", f.Name, limit)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, limit))
+}
+
+// Binary XML (AXML) của Android, xem ResourceTypes.h trong AOSP
+const (
+	axmlStringPool   = 0x0001
+	axmlXml          = 0x0003
+	axmlStartElement = 0x0102
+	axmlResourceMap  = 0x0180
+
+	axmlTypeReference = 0x01
+	axmlTypeString    = 0x03
+	axmlTypeIntDec    = 0x10
+	axmlTypeIntHex    = 0x11
+
+	axmlNoIndex = 0xFFFFFFFF
+
+	attrName        = 0x01010003
+	attrMinSdk      = 0x0101020c
+	attrVersionCode = 0x0101021b
+	attrVersionName = 0x0101021c
+	attrTargetSdk   = 0x01010270
+)
+
+var errCorruptXml = errors.New("corrupt binary XML")
+
+type axmlAttr struct {
+	name     string
+	resId    uint32
+	raw      string
+	dataType uint8
+	data     uint32
+}
+
+// str trả về giá trị chuỗi của attribute (string hoặc số)
+func (a axmlAttr) str() string {
+	switch a.dataType {
+	case axmlTypeString:
+		return a.raw
+	case axmlTypeIntDec, axmlTypeIntHex:
+		return strconv.FormatInt(int64(int32(a.data)), 10)
+	}
+	return a.raw
+}
+
+func (a axmlAttr) int() int64 {
+	switch a.dataType {
+	case axmlTypeIntDec, axmlTypeIntHex:
+		return int64(a.data)
+	case axmlTypeReference:
+		return 0
+	}
+	n, _ := strconv.ParseInt(a.raw, 10, 64)
+	return n
+}
+
+func parseBinaryManifest(data []byte, info *models.ApkInfo) error {
+	le := binary.LittleEndian
+	if len(data) < 8 || le.Uint16(data) != axmlXml {
+		return errors.New("not a binary XML document")
+	}
+	var strs []string
+	var resIds []uint32
+	str := func(i uint32) string {
+		if int64(i) < int64(len(strs)) {
+			return strs[i]
+		}
+		return ""
+	}
+	for off := int(le.Uint16(data[2:])); off+8 <= len(data); {
+		typ := le.Uint16(data[off:])
+		hsize := int(le.Uint16(data[off+2:]))
+		size := int(le.Uint32(data[off+4:]))
+		if size < 8 || hsize > size || size > len(data)-off {
+			return errCorruptXml
+		}
+		chunk := data[off : off+size]
+		off += size
+
+		switch typ {
+		case axmlStringPool:
+			var err error
+			if strs, err = parseStringPool(chunk); err != nil {
+				return err
+			}
+		case axmlResourceMap:
+			for i := hsize; i+4 <= size; i += 4 {
+				resIds = append(resIds, le.Uint32(chunk[i:]))
+			}
+		case axmlStartElement:
+			if hsize+20 > size {
+				return errCorruptXml
+			}
+			ext := chunk[hsize:]
+			element := str(le.Uint32(ext[4:]))
+			attrStart := int(le.Uint16(ext[8:]))
+			attrSize := int(le.Uint16(ext[10:]))
+			attrCount := int(le.Uint16(ext[12:]))
+			if attrSize < 20 || hsize+attrStart+attrSize*attrCount > size {
+				return errCorruptXml
+			}
+			attrs := make([]axmlAttr, attrCount)
+			for i := range attrs {
+				a := ext[attrStart+i*attrSize:]
+				nameIdx := le.Uint32(a[4:])
+				attrs[i] = axmlAttr{name: str(nameIdx), dataType: a[15], data: le.Uint32(a[16:])}
+				if int64(nameIdx) < int64(len(resIds)) {
+					attrs[i].resId = resIds[nameIdx]
+				}
+				if raw := le.Uint32(a[8:]); raw != axmlNoIndex {
+					attrs[i].raw = str(raw)
+				} else if attrs[i].dataType == axmlTypeString {
+					attrs[i].raw = str(attrs[i].data)
+				}
+			}
+			applyManifestElement(info, element, attrs)
+		}
+	}
+	return nil
+}
+
+// applyManifestElement lấy các attribute cần dùng; ưu tiên resource id vì tên attribute có thể bị obfuscate
+func applyManifestElement(info *models.ApkInfo, element string, attrs []axmlAttr) {
+	find := func(resId uint32, name string) (axmlAttr, bool) {
+		for _, a := range attrs {
+			if (resId != 0 && a.resId == resId) || (a.resId == 0 && a.name == name) {
+				return a, true
+			}
+		}
+		return axmlAttr{}, false
+	}
+	switch element {
+	case "manifest":
+		if a, ok := find(0, "package"); ok {
+			info.PackageName = a.str()
+		}
+		if a, ok := find(attrVersionCode, "versionCode"); ok {
+			info.VersionCode = a.int()
+		}
+		if a, ok := find(attrVersionName, "versionName"); ok {
+			info.VersionName = a.str()
+		}
+	case "uses-sdk":
+		if a, ok := find(attrMinSdk, "minSdkVersion"); ok {
+			info.MinSdk = int(a.int())
+		}
+		if a, ok := find(attrTargetSdk, "targetSdkVersion"); ok {
+			info.TargetSdk = int(a.int())
+		}
+	case "uses-permission", "uses-permission-sdk-23":
+		if a, ok := find(attrName, "name"); ok && a.str() != "" && !containsString(info.Permissions, a.str()) {
+			info.Permissions = append(info.Permissions, a.str())
+		}
+	}
+}
+
+func parseStringPool(chunk []byte) ([]string, error) {
+	le := binary.LittleEndian
+	if len(chunk) < 28 {
+		return nil, errCorruptXml
+	}
+	hsize := int(le.Uint16(chunk[2:]))
+	count := int(le.Uint32(chunk[8:]))
+	isUtf8 := le.Uint32(chunk[16:])&(1<<8) != 0
+	start := int(le.Uint32(chunk[20:]))
+	if count < 0 || count > len(chunk)/4 || hsize+count*4 > len(chunk) || start > len(chunk) {
+		return nil, errCorruptXml
+	}
+	strs := make([]string, count)
+	for i := range strs {
+		pos := start + int(le.Uint32(chunk[hsize+i*4:]))
+		if pos < start || pos >= len(chunk) {
+			return nil, errCorruptXml
+		}
+		var ok bool
+		if isUtf8 {
+			strs[i], ok = decodeUtf8String(chunk[pos:])
+		} else {
+			strs[i], ok = decodeUtf16String(chunk[pos:])
+		}
+		if !ok {
+			return nil, errCorruptXml
+		}
+	}
+	return strs, nil
+}
+
+// Chuỗi UTF-8 trong pool: độ dài UTF-16, độ dài UTF-8 (mỗi cái 1-2 byte), rồi tới dữ liệu
+func decodeUtf8String(b []byte) (string, bool) {
+	skipLen := func(b []byte) (int, []byte, bool) {
+		if len(b) < 1 {
+			return 0, nil, false
+		}
+		if b[0]&0x80 == 0 {
+			return int(b[0]), b[1:], true
+		}
+		if len(b) < 2 {
+			return 0, nil, false
+		}
+		return int(b[0]&0x7f)<<8 | int(b[1]), b[2:], true
+	}
+	_, b, ok := skipLen(b)
+	if !ok {
+		return "", false
+	}
+	n, b, ok := skipLen(b)
+	if !ok || n > len(b) {
+		return "", false
+	}
+	return string(b[:n]), true
+}
+
+func decodeUtf16String(b []byte) (string, bool) {
+	le := binary.LittleEndian
+	if len(b) < 2 {
+		return "", false
+	}
+	n := int(le.Uint16(b))
+	b = b[2:]
+	if n&0x8000 != 0 {
+		if len(b) < 2 {
+			return "", false
+		}
+		n = (n&0x7fff)<<16 | int(le.Uint16(b))
+		b = b[2:]
+	}
+	if n*2 > len(b) {
+		return "", false
+	}
+	units := make([]uint16, n)
+	for i := range units {
+		units[i] = le.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(units)), true
+}
+
+var errNoSigningBlock = errors.New("no APK signing block")
+
+const (
+	apkSigV2BlockId = 0x7109871a
+	apkSigV3BlockId = 0xf05368c0
+)
+
+// apkSigningCert trả về DER của chứng chỉ đầu tiên trong APK Signing Block (ưu tiên v3)
+func apkSigningCert(r io.ReaderAt, size int64) ([]byte, error) {
+	le := binary.LittleEndian
+	tailLen := int64(22 + 0xffff)
+	if tailLen > size {
+		tailLen = size
+	}
+	tail := make([]byte, tailLen)
+	if _, err := r.ReadAt(tail, size-tailLen); err != nil && err != io.EOF {
+		return nil, err
+	}
+	eocd := -1
+	for i := len(tail) - 22; i >= 0; i-- {
+		if le.Uint32(tail[i:]) == 0x06054b50 {
+			eocd = i
+			break
+		}
+	}
+	if eocd < 0 {
+		return nil, errors.New("no zip end of central directory")
+	}
+	cdOffset := int64(le.Uint32(tail[eocd+16:]))
+	if cdOffset < 32 || cdOffset > size {
+		return nil, errNoSigningBlock
+	}
+	footer := make([]byte, 24)
+	if _, err := r.ReadAt(footer, cdOffset-24); err != nil {
+		return nil, err
+	}
+	if string(footer[8:]) != "APK Sig Block 42" {
+		return nil, errNoSigningBlock
+	}
+	blockSize := int64(le.Uint64(footer))
+	if blockSize < 24 || blockSize > 16<<20 || cdOffset-blockSize-8 < 0 {
+		return nil, errors.New("invalid APK signing block size")
+	}
+	pairs := make([]byte, blockSize-24)
+	if _, err := r.ReadAt(pairs, cdOffset-blockSize); err != nil {
+		return nil, err
+	}
+
+	var v2, v3 []byte
+	for len(pairs) >= 12 {
+		n := le.Uint64(pairs)
+		if n < 4 || n > uint64(len(pairs)-8) {
+			return nil, errors.New("invalid APK signing block entry")
+		}
+		switch le.Uint32(pairs[8:]) {
+		case apkSigV2BlockId:
+			v2 = pairs[12 : 8+n]
+		case apkSigV3BlockId:
+			v3 = pairs[12 : 8+n]
+		}
+		pairs = pairs[8+n:]
+	}
+	for _, scheme := range [][]byte{v3, v2} {
+		if scheme == nil {
+			continue
+		}
+		// signers > signer > signed data > (digests, certificates > certificate)
+		signers, _, ok := lengthPrefixed(scheme)
+		signer, _, ok2 := lengthPrefixed(signers)
+		signedData, _, ok3 := lengthPrefixed(signer)
+		_, rest, ok4 := lengthPrefixed(signedData)
+		certs, _, ok5 := lengthPrefixed(rest)
+		cert, _, ok6 := lengthPrefixed(certs)
+		if ok && ok2 && ok3 && ok4 && ok5 && ok6 && len(cert) > 0 {
+			return cert, nil
+		}
+		return nil, errors.New("malformed APK signature scheme block")
+	}
+	return nil, errNoSigningBlock
+}
+
+// lengthPrefixed tách một phần tử có tiền tố độ dài uint32 little-endian
+func lengthPrefixed(b []byte) ([]byte, []byte, bool) {
+	if len(b) < 4 {
+		return nil, nil, false
+	}
+	n := binary.LittleEndian.Uint32(b)
+	if uint64(n) > uint64(len(b)-4) {
+		return nil, nil, false
+	}
+	return b[4 : 4+n], b[4+n:], true
+}
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     pkcs7RawCertificates `asn1:"optional,tag:0"`
+}
+
+type pkcs7RawCertificates struct {
+	Raw asn1.RawContent
+}
+
+// pkcs7FirstCert lấy chứng chỉ đầu tiên trong chữ ký v1 (META-INF/*.RSA)
+func pkcs7FirstCert(der []byte) ([]byte, error) {
+	var ci pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, err
+	}
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, err
+	}
+	if len(sd.Certificates.Raw) == 0 {
+		return nil, errors.New("signature has no certificates")
+	}
+	var set, cert asn1.RawValue
+	if _, err := asn1.Unmarshal(sd.Certificates.Raw, &set); err != nil {
+		return nil, err
+	}
+	if _, err := asn1.Unmarshal(set.Bytes, &cert); err != nil {
+		return nil, err
+	}
+	return cert.FullBytes, nil
+}
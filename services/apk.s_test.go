@@ -0,0 +1,157 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+type testAttr struct {
+	name     uint32
+	raw      uint32
+	dataType uint8
+	data     uint32
+}
+
+type testElement struct {
+	name  uint32
+	attrs []testAttr
+}
+
+// buildAxml mã hoá một manifest tối giản theo định dạng binary XML (string pool UTF-16)
+func buildAxml(strs []string, resIds []uint32, elements []testElement) []byte {
+	le := binary.LittleEndian
+	var pool bytes.Buffer
+	offsets := make([]uint32, len(strs))
+	for i, s := range strs {
+		offsets[i] = uint32(pool.Len())
+		units := utf16.Encode([]rune(s))
+		binary.Write(&pool, le, uint16(len(units)))
+		binary.Write(&pool, le, units)
+		binary.Write(&pool, le, uint16(0))
+	}
+	for pool.Len()%4 != 0 {
+		pool.WriteByte(0)
+	}
+	var body bytes.Buffer
+	header := 28 + 4*len(strs)
+	binary.Write(&body, le, []uint16{axmlStringPool, 28})
+	binary.Write(&body, le, []uint32{uint32(header + pool.Len()), uint32(len(strs)), 0, 0, uint32(header), 0})
+	binary.Write(&body, le, offsets)
+	body.Write(pool.Bytes())
+
+	binary.Write(&body, le, []uint16{axmlResourceMap, 8})
+	binary.Write(&body, le, uint32(8+4*len(resIds)))
+	binary.Write(&body, le, resIds)
+
+	for _, e := range elements {
+		binary.Write(&body, le, []uint16{axmlStartElement, 16})
+		binary.Write(&body, le, []uint32{uint32(36 + 20*len(e.attrs)), 1, axmlNoIndex, axmlNoIndex, e.name})
+		binary.Write(&body, le, []uint16{20, 20, uint16(len(e.attrs)), 0, 0, 0})
+		for _, a := range e.attrs {
+			binary.Write(&body, le, []uint32{axmlNoIndex, a.name, a.raw})
+			binary.Write(&body, le, []uint16{8})
+			binary.Write(&body, le, []uint8{0, a.dataType})
+			binary.Write(&body, le, a.data)
+		}
+	}
+
+	var doc bytes.Buffer
+	binary.Write(&doc, le, []uint16{axmlXml, 8})
+	binary.Write(&doc, le, uint32(8+body.Len()))
+	doc.Write(body.Bytes())
+	return doc.Bytes()
+}
+
+func lp(parts ...[]byte) []byte {
+	var b bytes.Buffer
+	for _, p := range parts {
+		binary.Write(&b, binary.LittleEndian, uint32(len(p)))
+		b.Write(p)
+	}
+	return b.Bytes()
+}
+
+// withV2Signature chèn APK Signing Block chứa cert ngay trước central directory
+func withV2Signature(apk, cert []byte) []byte {
+	le := binary.LittleEndian
+	signedData := append(append(lp([]byte{}), lp(lp(cert))...), lp([]byte{})...)
+	signer := append(append(lp(signedData), lp([]byte{})...), lp([]byte{1})...)
+	value := lp(lp(signer))
+
+	var pairs bytes.Buffer
+	binary.Write(&pairs, le, uint64(len(value)+4))
+	binary.Write(&pairs, le, uint32(apkSigV2BlockId))
+	pairs.Write(value)
+	size := uint64(pairs.Len() + 24)
+	var block bytes.Buffer
+	binary.Write(&block, le, size)
+	block.Write(pairs.Bytes())
+	binary.Write(&block, le, size)
+	block.WriteString("APK Sig Block 42")
+
+	eocd := bytes.LastIndex(apk, []byte{0x50, 0x4b, 0x05, 0x06})
+	cdOffset := le.Uint32(apk[eocd+16:])
+	out := append(append(append([]byte{}, apk[:cdOffset]...), block.Bytes()...), apk[cdOffset:]...)
+	le.PutUint32(out[eocd+block.Len()+16:], cdOffset+uint32(block.Len()))
+	return out
+}
+
+func TestInspectApk(t *testing.T) {
+	strs := []string{
+		"", "", "", "", // tên attribute bị obfuscate, chỉ còn resource id
+		"package", "manifest", "uses-sdk", "uses-permission",
+		"com.example.pwa", "1.4.0", "android.permission.INTERNET", "targetSdkVersion",
+	}
+	resIds := []uint32{attrVersionCode, attrVersionName, attrMinSdk, attrName}
+	manifest := buildAxml(strs, resIds, []testElement{
+		{name: 5, attrs: []testAttr{
+			{name: 0, raw: axmlNoIndex, dataType: axmlTypeIntDec, data: 42},
+			{name: 1, raw: 9, dataType: axmlTypeString, data: 9},
+			{name: 4, raw: 8, dataType: axmlTypeString, data: 8},
+		}},
+		{name: 6, attrs: []testAttr{
+			{name: 2, raw: axmlNoIndex, dataType: axmlTypeIntDec, data: 24},
+			{name: 11, raw: axmlNoIndex, dataType: axmlTypeIntDec, data: 34},
+		}},
+		{name: 7, attrs: []testAttr{{name: 3, raw: 10, dataType: axmlTypeString, data: 10}}},
+		{name: 7, attrs: []testAttr{{name: 3, raw: 10, dataType: axmlTypeString, data: 10}}},
+	})
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("AndroidManifest.xml")
+	w.Write(manifest)
+	w, _ = zw.Create("classes.dex")
+	w.Write([]byte("dex\n035"))
+	zw.Close()
+	cert := []byte("not really DER, only hashed")
+	apk := withV2Signature(buf.Bytes(), cert)
+
+	info, err := InspectApk(bytes.NewReader(apk), int64(len(apk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(cert)
+	if info.PackageName != "com.example.pwa" || info.VersionCode != 42 || info.VersionName != "1.4.0" {
+		t.Errorf("package/version = %s %d %s", info.PackageName, info.VersionCode, info.VersionName)
+	}
+	if info.MinSdk != 24 || info.TargetSdk != 34 {
+		t.Errorf("sdk = %d/%d, want 24/34", info.MinSdk, info.TargetSdk)
+	}
+	if !reflect.DeepEqual([]string(info.Permissions), []string{"android.permission.INTERNET"}) {
+		t.Errorf("permissions = %v", info.Permissions)
+	}
+	if info.CertSha256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("cert fingerprint = %s", info.CertSha256)
+	}
+
+	if _, err := InspectApk(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err == nil {
+		t.Error("unsigned apk accepted")
+	}
+}
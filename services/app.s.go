@@ -1,32 +1,246 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"waheim.api/configs"
 	"waheim.api/models"
 	"waheim.api/repositories"
 )
 
-type AppService struct{}
+// appEditableColumns là các cột publisher được sửa qua PUT /app/:id. Các cột còn lại do hệ thống
+// quản lý (điểm xếp hạng, lượt tải, yêu thích, giám sát, APK...) và không được ghi từ request.
+var appEditableColumns = []string{
+	"name", "description", "uri", "icon", "screenshots", "category", "tags",
+	"theme_color", "background_color", "icons", "status",
+}
+
+// AppService: một user (hoặc IP với người dùng ẩn danh) chỉ được tính một lượt cài
+// mỗi app và source trong InstallWindow
+type AppService struct {
+	Crawler       *Crawler
+	Catalog       *CatalogService
+	InstallWindow time.Duration
+}
 
 func NewAppService() *AppService {
-	return &AppService{}
+	return &AppService{
+		Crawler:       NewCrawler(),
+		Catalog:       NewCatalogService(),
+		InstallWindow: time.Duration(envInt("INSTALL_DEDUPE_HOURS", 24)) * time.Hour,
+	}
+}
+
+// CreateApp kiểm tra URI truy cập được trước khi lưu, các trường bỏ trống
+// được điền từ title, meta và web manifest của site
+// CreateApp tạo app mới; status rỗng được hiểu là draft, chỉ nhận draft hoặc published
+func (s *AppService) CreateApp(ctx context.Context, app *models.App) error {
+	switch app.Status {
+	case "":
+		app.Status = models.AppStatusDraft
+	case models.AppStatusDraft, models.AppStatusPublished:
+	default:
+		return errors.New(configs.GetErrString(configs.ErrorCode_INVALID_REQUEST))
+	}
+	var err error
+	if app.Category, err = s.Catalog.NormalizeCategory(ctx, app.Category); err != nil {
+		return err
+	}
+	if app.Tags, err = s.Catalog.NormalizeTags(ctx, app.Tags); err != nil {
+		return err
+	}
+	meta, err := s.Crawler.Inspect(ctx, app.Uri)
+	if err != nil {
+		return err
+	}
+	category := app.Category
+	applySiteMetadata(app, meta)
+	if category == "" && app.Category != "" {
+		// Category lấy từ manifest chỉ giữ lại nếu có trong danh mục
+		if app.Category, err = s.Catalog.NormalizeCategory(ctx, app.Category); err != nil {
+			app.Category = ""
+		}
+	}
+	if err := validateAppTheme(app.ThemeColor, app.BackgroundColor, app.Icons); err != nil {
+		return err
+	}
+	return repositories.CreateApp(ctx, app)
 }
 
-func (s *AppService) CreateApp(app *models.App) error {
-	return repositories.CreateApp(app)
+func (s *AppService) GetAppById(ctx context.Context, id string) (models.App, error) {
+	return repositories.GetAppById(ctx, id)
 }
 
-func (s *AppService) GetAppById(id string) (models.App, error) {
-	return repositories.GetAppById(id)
+// GetAllApps liệt kê app; sort là "top", "trending" hoặc rỗng (không sắp xếp)
+func (s *AppService) GetAllApps(ctx context.Context, limit, offset int, sort string) ([]models.App, error) {
+	if _, ok := repositories.AppSortOrders[sort]; sort != "" && !ok {
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_REQUEST))
+	}
+	return repositories.GetAllApps(ctx, limit, offset, sort)
+}
+
+func (s *AppService) UpdateApp(ctx context.Context, id string, updates map[string]interface{}) error {
+	invalid := errors.New(configs.GetErrString(configs.ErrorCode_INVALID_REQUEST))
+	for column := range updates {
+		if !containsString(appEditableColumns, column) {
+			return invalid
+		}
+	}
+	if v, ok := updates["status"]; ok {
+		if status, _ := v.(string); status != models.AppStatusDraft && status != models.AppStatusPublished {
+			return invalid
+		}
+	}
+	if v, ok := updates["screenshots"]; ok {
+		var screenshots []string
+		data, _ := json.Marshal(v)
+		if err := json.Unmarshal(data, &screenshots); err != nil {
+			return invalid
+		}
+		updates["screenshots"] = pq.StringArray(screenshots)
+	}
+	if v, ok := updates["category"]; ok {
+		category, _ := v.(string)
+		slug, err := s.Catalog.NormalizeCategory(ctx, category)
+		if err != nil {
+			return err
+		}
+		updates["category"] = slug
+	}
+	if v, ok := updates["tags"]; ok {
+		var tags []string
+		data, _ := json.Marshal(v)
+		if err := json.Unmarshal(data, &tags); err != nil {
+			return errors.New(configs.GetErrString(configs.ErrorCode_INVALID_TAG))
+		}
+		slugs, err := s.Catalog.NormalizeTags(ctx, tags)
+		if err != nil {
+			return err
+		}
+		updates["tags"] = slugs
+	}
+	var icons models.AppIcons
+	if v, ok := updates["icons"]; ok {
+		// icons từ JSON là []interface{}, chuyển sang AppIcons để lưu dạng JSONB
+		data, _ := json.Marshal(v)
+		if err := json.Unmarshal(data, &icons); err != nil {
+			return errors.New(configs.GetErrString(configs.ErrorCode_INVALID_APP_ICON))
+		}
+		updates["icons"] = icons
+	}
+	colors := map[string]string{}
+	for _, column := range []string{"theme_color", "background_color"} {
+		if v, ok := updates[column]; ok {
+			color, isString := v.(string)
+			if !isString {
+				return invalid
+			}
+			colors[column] = color
+		}
+	}
+	if v, ok := updates["uri"]; ok {
+		uri, isString := v.(string)
+		if !isString {
+			return invalid
+		}
+		meta, err := s.Crawler.Inspect(ctx, uri)
+		if err != nil {
+			return err
+		}
+		app, err := repositories.GetAppById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if app.Uri != uri {
+			filled := siteMetadataUpdates(app, updates, meta)
+			if v, ok := filled["category"]; ok {
+				// Category lấy từ manifest chỉ giữ lại nếu có trong danh mục
+				if filled["category"], err = s.Catalog.NormalizeCategory(ctx, v.(string)); err != nil {
+					delete(filled, "category")
+				}
+			}
+			for column, v := range filled {
+				updates[column] = v
+			}
+			if v, ok := filled["theme_color"]; ok {
+				colors["theme_color"] = v.(string)
+			}
+			if v, ok := filled["background_color"]; ok {
+				colors["background_color"] = v.(string)
+			}
+			if v, ok := filled["icons"]; ok {
+				icons = v.(models.AppIcons)
+			}
+		}
+	}
+	themeColor, backgroundColor := colors["theme_color"], colors["background_color"]
+	if err := validateAppTheme(themeColor, backgroundColor, icons); err != nil {
+		return err
+	}
+	return repositories.UpdateApp(ctx, id, updates)
+}
+
+// siteMetadataUpdates trả về các cột được điền từ metadata của site mới: chỉ những trường vẫn
+// trống sau khi áp updates lên app hiện tại, giống lúc tạo app
+func siteMetadataUpdates(app models.App, updates map[string]interface{}, meta SiteMetadata) map[string]interface{} {
+	fields := map[string]*string{
+		"name": &app.Name, "description": &app.Description, "icon": &app.Icon,
+		"theme_color": &app.ThemeColor, "background_color": &app.BackgroundColor, "category": &app.Category,
+	}
+	before := map[string]string{}
+	for column, field := range fields {
+		if v, ok := updates[column].(string); ok {
+			*field = v
+		}
+		before[column] = *field
+	}
+	if v, ok := updates["icons"].(models.AppIcons); ok {
+		app.Icons = v
+	}
+	hadIcons := len(app.Icons) > 0
+	applySiteMetadata(&app, meta)
+
+	filled := map[string]interface{}{}
+	for column, field := range fields {
+		if *field != before[column] {
+			filled[column] = *field
+		}
+	}
+	if !hadIcons && len(app.Icons) > 0 {
+		filled["icons"] = app.Icons
+	}
+	return filled
 }
 
-func (s *AppService) GetAllApps(limit, offset int) ([]models.App, error) {
-	return repositories.GetAllApps(limit, offset)
+// RecordInstall ghi một lượt cài; userId rỗng với người dùng ẩn danh, khi đó lượt cài
+// được chống trùng theo IP
+func (s *AppService) RecordInstall(ctx context.Context, appId, userId, ip, source string) (models.AppInstall, error) {
+	install := models.AppInstall{
+		AppId:     appId,
+		UserId:    sql.NullString{String: userId, Valid: userId != ""},
+		Installer: installerKey(userId, ip),
+		Source:    source,
+	}
+	err := repositories.RecordAppInstall(ctx, &install, s.InstallWindow)
+	return install, err
 }
 
-func (s *AppService) UpdateApp(id string, updates map[string]interface{}) error {
-	return repositories.UpdateApp(id, updates)
+// installerKey không lưu IP thô, chỉ lưu một phần hash đủ để nhận ra lượt cài lặp lại
+func installerKey(userId, ip string) string {
+	if userId != "" {
+		return "user:" + userId
+	}
+	sum := sha256.Sum256([]byte(ip))
+	return "ip:" + hex.EncodeToString(sum[:12])
 }
 
-func (s *AppService) DeleteApp(id string) error {
-	return repositories.DeleteApp(id)
+func (s *AppService) DeleteApp(ctx context.Context, id string) error {
+	return repositories.DeleteApp(ctx, id)
 }
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+func TestUpdateAppRejectsSystemColumns(t *testing.T) {
+	s := &AppService{}
+	columns := []string{
+		"rating_score", "trending_score", "rating_count", "downloads", "android_install_uri",
+		"rating", "publisher_id", "id", "deleted_at",
+		"health_status", "health_failures", "health_checked_at", "favorite_count",
+	}
+	for _, column := range columns {
+		err := s.UpdateApp(context.Background(), "app", map[string]interface{}{"name": "Notes", column: 1})
+		if err == nil || err.Error() != configs.GetErrString(configs.ErrorCode_INVALID_REQUEST) {
+			t.Errorf("%s: got %v, want INVALID_REQUEST", column, err)
+		}
+	}
+	err := s.UpdateApp(context.Background(), "app", map[string]interface{}{"status": "approved"})
+	if err == nil || err.Error() != configs.GetErrString(configs.ErrorCode_INVALID_REQUEST) {
+		t.Errorf("unknown status: got %v", err)
+	}
+	for _, column := range []string{"theme_color", "background_color"} {
+		for _, v := range []interface{}{123, true, map[string]interface{}{"hex": "#fff"}, nil} {
+			err := s.UpdateApp(context.Background(), "app", map[string]interface{}{column: v})
+			if err == nil || err.Error() != configs.GetErrString(configs.ErrorCode_INVALID_REQUEST) {
+				t.Errorf("%s=%v: got %v, want INVALID_REQUEST", column, v, err)
+			}
+		}
+	}
+}
+
+// Status lạ phải bị từ chối trước khi crawl site hay ghi DB (app.published dựa vào status)
+func TestCreateAppRejectsUnknownStatus(t *testing.T) {
+	s := &AppService{}
+	for _, status := range []string{"approved", "PUBLISHED", "hidden"} {
+		err := s.CreateApp(context.Background(), &models.App{Name: "Notes", Status: status})
+		if err == nil || err.Error() != configs.GetErrString(configs.ErrorCode_INVALID_REQUEST) {
+			t.Errorf("%q: got %v, want INVALID_REQUEST", status, err)
+		}
+	}
+}
+
+// Đổi Uri chỉ điền các trường còn trống, không ghi đè dữ liệu publisher đã nhập hay vừa gửi
+func TestSiteMetadataUpdatesFillsOnlyEmptyFields(t *testing.T) {
+	app := models.App{Name: "Notes", ThemeColor: "#112233"}
+	meta := SiteMetadata{
+		Title:       "Site title",
+		Description: "From site",
+		Icon:        "https://notes.example/favicon.png",
+		ThemeColor:  "#abcdef",
+		Manifest: &SiteManifest{
+			Name:            "Manifest name",
+			BackgroundColor: "#000000",
+			Icons:           []models.AppIcon{{Src: "https://notes.example/icon-192.png", Sizes: "192x192"}},
+			Categories:      []string{"productivity"},
+		},
+	}
+	filled := siteMetadataUpdates(app, map[string]interface{}{"uri": "https://notes.example/", "background_color": "#ffffff"}, meta)
+
+	want := map[string]interface{}{
+		"description": "From site",
+		"icon":        "https://notes.example/favicon.png",
+		"category":    "productivity",
+	}
+	for column, v := range want {
+		if filled[column] != v {
+			t.Errorf("%s = %v, want %v", column, filled[column], v)
+		}
+	}
+	for _, column := range []string{"name", "theme_color", "background_color"} {
+		if v, ok := filled[column]; ok {
+			t.Errorf("%s overwritten with %v", column, v)
+		}
+	}
+	if icons, _ := filled["icons"].(models.AppIcons); len(icons) != 1 {
+		t.Errorf("icons = %v", filled["icons"])
+	}
+}
+
+func TestInstallerKey(t *testing.T) {
+	if got := installerKey("u1", "203.0.113.7"); got != "user:u1" {
+		t.Errorf("signed in installer = %q", got)
+	}
+	a, b := installerKey("", "203.0.113.7"), installerKey("", "203.0.113.8")
+	if a == b || a != installerKey("", "203.0.113.7") || strings.Contains(a, "203.0.113") {
+		t.Errorf("anonymous installer keys %q, %q", a, b)
+	}
+}
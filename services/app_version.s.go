@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+// AppVersionService nhận APK (từ build hoặc publisher upload), đọc metadata và lưu vào storage
+type AppVersionService struct {
+	BaseUrl    string
+	MaxApkSize int64
+}
+
+// NewAppVersionService đọc PUBLIC_BASE_URL và ARTIFACT_MAX_MB (mặc định 200)
+func NewAppVersionService() *AppVersionService {
+	return &AppVersionService{
+		BaseUrl:    os.Getenv("PUBLIC_BASE_URL"),
+		MaxApkSize: int64(envInt("ARTIFACT_MAX_MB", 200)) << 20,
+	}
+}
+
+// DownloadUrl là link cài đặt ổn định của app, luôn trỏ tới APK mới nhất
+func (s *AppVersionService) DownloadUrl(appId string) string {
+	return s.BaseUrl + "/app/" + appId + "/download/android"
+}
+
+// Ingest kiểm tra APK, từ chối package name đã thuộc publisher khác rồi lưu file vào storage.
+// Version trả về chưa được ghi vào DB.
+func (s *AppVersionService) Ingest(ctx context.Context, app models.App, apk *os.File, size int64) (models.AppVersion, error) {
+	v := models.AppVersion{AppId: app.Id, ApkSize: size}
+	info, err := InspectApk(apk, size)
+	if err != nil {
+		logger.InfoContext(ctx, "apk rejected", "app_id", app.Id, "error", err)
+		return v, fmt.Errorf("%s: %w", configs.GetErrString(configs.ErrorCode_INVALID_APK), err)
+	}
+	v.ApkInfo = info
+	taken, err := repositories.IsPackageTaken(ctx, info.PackageName, app.PublisherId)
+	if err != nil {
+		return v, err
+	}
+	if taken {
+		return v, errors.New(configs.GetErrString(configs.ErrorCode_APK_PACKAGE_CONFLICT))
+	}
+
+	hash := sha256.New()
+	if _, err := apk.Seek(0, io.SeekStart); err != nil {
+		return v, err
+	}
+	if _, err := io.Copy(hash, apk); err != nil {
+		return v, err
+	}
+	v.ApkSha256 = hex.EncodeToString(hash.Sum(nil))
+	if _, err := apk.Seek(0, io.SeekStart); err != nil {
+		return v, err
+	}
+	// Key theo checksum nên cùng một APK chỉ lưu một lần
+	v.ApkKey = fmt.Sprintf("apps/%s/apk/%s.apk", app.Id, v.ApkSha256)
+	if _, err := configs.Storage.Put(ctx, v.ApkKey, apk); err != nil {
+		return v, err
+	}
+	configs.UploadSizeBytes.WithLabelValues("apk").Observe(float64(size))
+	return v, nil
+}
+
+// Upload nhận APK do publisher tải lên và đặt làm version mới nhất của app
+func (s *AppVersionService) Upload(ctx context.Context, app models.App, r io.Reader) (models.AppVersion, error) {
+	tmp, err := os.CreateTemp("", "upload-*.apk")
+	if err != nil {
+		return models.AppVersion{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, io.LimitReader(r, s.MaxApkSize+1))
+	if err != nil {
+		return models.AppVersion{}, fmt.Errorf("%s: reading upload: %w", configs.GetErrString(configs.ErrorCode_INVALID_REQUEST), err)
+	}
+	if size > s.MaxApkSize {
+		return models.AppVersion{}, fmt.Errorf("%s: apk larger than %d bytes", configs.GetErrString(configs.ErrorCode_INVALID_APK), s.MaxApkSize)
+	}
+	v, err := s.Ingest(ctx, app, tmp, size)
+	if err != nil {
+		return v, err
+	}
+	v.Source = models.AppVersionSourceUpload
+	v.BuildId = sql.NullString{}
+	if err := repositories.CreateAppVersion(ctx, &v, s.DownloadUrl(app.Id)); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+func (s *AppVersionService) GetLatest(ctx context.Context, appId string) (models.AppVersion, error) {
+	return repositories.GetLatestAppVersion(ctx, appId)
+}
+
+// OpenLatest mở APK mới nhất của app để phục vụ tải về
+func (s *AppVersionService) OpenLatest(ctx context.Context, appId string) (models.AppVersion, *configs.Blob, error) {
+	if _, err := repositories.GetAppById(ctx, appId); err != nil {
+		return models.AppVersion{}, nil, err
+	}
+	v, err := repositories.GetLatestAppVersion(ctx, appId)
+	if err != nil {
+		return v, nil, err
+	}
+	blob, err := configs.Storage.Open(ctx, v.ApkKey)
+	if err != nil {
+		logger.ErrorContext(ctx, "apk missing from storage", "version_id", v.Id, "error", err)
+		return v, nil, errors.New(configs.GetErrString(configs.ErrorCode_APK_NOT_AVAILABLE))
+	}
+	return v, blob, nil
+}
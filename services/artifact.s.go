@@ -0,0 +1,85 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// downloadArtifactApk tải artifact zip của GitHub và giải nén file .apk bên trong ra file tạm.
+// Link artifact của GitHub cần token và sẽ hết hạn nên APK phải được mirror vào storage.
+// Caller phải đóng và xoá file trả về.
+func (s *BuildService) downloadArtifactApk(ctx context.Context, archiveUrl string) (*os.File, int64, error) {
+	archive, err := os.CreateTemp("", "artifact-*.zip")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", archiveUrl, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	// GitHub trả 302 tới storage của họ; http.Client bỏ Authorization khi redirect sang domain khác
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, 0, fmt.Errorf("artifact download responded %d", resp.StatusCode)
+	}
+	n, err := io.Copy(archive, io.LimitReader(resp.Body, s.MaxArtifactSize+1))
+	if err != nil {
+		return nil, 0, err
+	}
+	if n > s.MaxArtifactSize {
+		return nil, 0, fmt.Errorf("artifact larger than %d bytes", s.MaxArtifactSize)
+	}
+
+	zr, err := zip.NewReader(archive, n)
+	if err != nil {
+		return nil, 0, fmt.Errorf("artifact is not a zip: %w", err)
+	}
+	var entry *zip.File
+	for _, f := range zr.File {
+		if strings.EqualFold(path.Ext(f.Name), ".apk") && !f.FileInfo().IsDir() {
+			entry = f
+			break
+		}
+	}
+	if entry == nil {
+		return nil, 0, errors.New("artifact contains no .apk file")
+	}
+	if entry.UncompressedSize64 > uint64(s.MaxArtifactSize) {
+		return nil, 0, fmt.Errorf("apk larger than %d bytes", s.MaxArtifactSize)
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rc.Close()
+	apk, err := os.CreateTemp("", "build-*.apk")
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := io.Copy(apk, io.LimitReader(rc, s.MaxArtifactSize+1))
+	if err == nil && size > s.MaxArtifactSize {
+		err = fmt.Errorf("apk larger than %d bytes", s.MaxArtifactSize)
+	}
+	if err != nil {
+		apk.Close()
+		os.Remove(apk.Name())
+		return nil, 0, err
+	}
+	return apk, size, nil
+}
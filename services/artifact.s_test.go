@@ -0,0 +1,78 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownloadArtifactApk(t *testing.T) {
+	archives := map[string][]byte{
+		"/ok":     zipArchive(t, map[string]string{"README.txt": "hi", "out/App-Release.APK": "apk-bytes"}),
+		"/no-apk": zipArchive(t, map[string]string{"README.txt": "hi"}),
+		"/big":    zipArchive(t, map[string]string{"app.apk": string(bytes.Repeat([]byte("x"), 4096))}),
+		"/junk":   []byte("not a zip"),
+	}
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, ok := archives[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+	s := &BuildService{Client: srv.Client(), Token: "gh-token", MaxArtifactSize: 2048}
+
+	apk, size, err := s.downloadArtifactApk(context.Background(), srv.URL+"/ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(apk.Name())
+	defer apk.Close()
+	apk.Seek(0, io.SeekStart)
+	content, _ := io.ReadAll(apk)
+	if string(content) != "apk-bytes" || size != int64(len("apk-bytes")) {
+		t.Errorf("got %q (%d bytes)", content, size)
+	}
+	if auth != "Bearer gh-token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	for _, path := range []string{"/no-apk", "/big", "/junk", "/expired"} {
+		if apk, _, err := s.downloadArtifactApk(context.Background(), srv.URL+path); err == nil {
+			apk.Close()
+			os.Remove(apk.Name())
+			t.Errorf("%s: expected error", path)
+		}
+	}
+
+	s.MaxArtifactSize = 16
+	if _, _, err := s.downloadArtifactApk(context.Background(), srv.URL+"/ok"); err == nil {
+		t.Error("archive over MaxArtifactSize accepted")
+	}
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+// GitHubWorkflowRunEvent là phần payload cần dùng của event workflow_run
+type GitHubWorkflowRunEvent struct {
+	Action      string            `json:"action"`
+	WorkflowRun GitHubWorkflowRun `json:"workflow_run"`
+}
+
+type GitHubWorkflowRun struct {
+	Id           int64  `json:"id"`
+	DisplayTitle string `json:"display_title"`
+	Event        string `json:"event"`
+	Conclusion   string `json:"conclusion"`
+	HtmlUrl      string `json:"html_url"`
+	ArtifactsUrl string `json:"artifacts_url"`
+}
+
+var buildIdPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// BuildService dispatch build APK lên GitHub Actions và nhận kết quả qua webhook workflow_run.
+// Workflow build-apk phải đặt run-name chứa client_payload.build_id, ví dụ
+// `run-name: build-apk ${{ github.event.client_payload.build_id }}`, để ghép run với build.
+type BuildService struct {
+	Client        *http.Client
+	Token         string
+	RepoOwner     string
+	RepoName      string
+	WebhookSecret string
+	Timeout       time.Duration
+	Versions      *AppVersionService
+	Pwa           *PwaService
+	// MaxArtifactSize giới hạn cả artifact zip lẫn APK bên trong (byte)
+	MaxArtifactSize int64
+	// MaxMirrorAttempts là số lần thử tải artifact trước khi build bị đánh dấu failed
+	MaxMirrorAttempts int
+}
+
+// NewBuildService đọc GITHUB_TOKEN, GITHUB_REPO_OWNER, GITHUB_REPO_NAME,
+// GITHUB_WEBHOOK_SECRET, BUILD_TIMEOUT_MINUTES (mặc định 60), ARTIFACT_MAX_MB (mặc định 200)
+// và BUILD_MIRROR_ATTEMPTS (mặc định 5)
+func NewBuildService() *BuildService {
+	return &BuildService{
+		Client:            configs.OutboundClient,
+		Token:             os.Getenv("GITHUB_TOKEN"),
+		RepoOwner:         os.Getenv("GITHUB_REPO_OWNER"),
+		RepoName:          os.Getenv("GITHUB_REPO_NAME"),
+		WebhookSecret:     os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		Timeout:           time.Duration(envInt("BUILD_TIMEOUT_MINUTES", 60)) * time.Minute,
+		Versions:          NewAppVersionService(),
+		Pwa:               NewPwaService(),
+		MaxArtifactSize:   int64(envInt("ARTIFACT_MAX_MB", 200)) << 20,
+		MaxMirrorAttempts: envInt("BUILD_MIRROR_ATTEMPTS", 5),
+	}
+}
+
+// Dispatch tạo build và gửi repository_dispatch; build lỗi dispatch được đánh dấu failed ngay
+func (s *BuildService) Dispatch(ctx context.Context, app models.App) (models.Build, error) {
+	build := models.Build{AppId: app.Id}
+	if err := repositories.CreateBuild(ctx, &build); err != nil {
+		return build, err
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"event_type": "build-apk",
+		"client_payload": map[string]string{
+			"url":          app.Uri,
+			"manifest_url": s.Pwa.ManifestUrl(app.Id),
+			"app_id":       app.Id,
+			"build_id":     build.Id,
+		},
+	})
+	apiUrl := fmt.Sprintf("https://api.github.com/repos/%s/%s/dispatches", s.RepoOwner, s.RepoName)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiUrl, bytes.NewReader(payload))
+	if err != nil {
+		return build, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.Client.Do(req)
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			err = fmt.Errorf("github dispatch failed: %d %s", resp.StatusCode, string(body))
+		}
+	}
+	if err != nil {
+		configs.BuildDispatchTotal.WithLabelValues("dispatch_failed").Inc()
+		build.Status = models.BuildStatusFailed
+		build.Error = sql.NullString{String: err.Error(), Valid: true}
+		if _, completeErr := repositories.CompleteBuild(ctx, &build, nil, ""); completeErr != nil {
+			// Build còn queued sẽ được FailStale đánh dấu failed khi hết timeout
+			logger.ErrorContext(ctx, "failed to mark build failed after dispatch error", "build_id", build.Id, "error", completeErr)
+		}
+		return build, err
+	}
+	configs.BuildDispatchTotal.WithLabelValues("dispatched").Inc()
+	return build, nil
+}
+
+// VerifyGitHubSignature kiểm tra header X-Hub-Signature-256 ("sha256=<hex>")
+func VerifyGitHubSignature(secret string, body []byte, signature string) bool {
+	if secret == "" {
+		return false
+	}
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func (s *BuildService) VerifySignature(body []byte, signature string) bool {
+	return VerifyGitHubSignature(s.WebhookSecret, body, signature)
+}
+
+// workflowRunBuildId lấy build id từ run-name (display_title) của run, rỗng nếu không có
+func workflowRunBuildId(run GitHubWorkflowRun) string {
+	return strings.ToLower(buildIdPattern.FindString(run.DisplayTitle))
+}
+
+// HandleWorkflowRun xử lý workflow_run completed. Run thành công chỉ được ghi lại (run id,
+// artifacts_url) để MirrorPending tải APK ngoài request webhook, vì GitHub huỷ delivery sau ~10s;
+// run lỗi thì đánh dấu build failed kèm link log. Run không khớp build nào thì bỏ qua.
+func (s *BuildService) HandleWorkflowRun(ctx context.Context, event GitHubWorkflowRunEvent) error {
+	run := event.WorkflowRun
+	if event.Action != "completed" || run.Event != "repository_dispatch" {
+		return nil
+	}
+	buildId := workflowRunBuildId(run)
+	if buildId == "" {
+		logger.InfoContext(ctx, "workflow run without build id ignored", "run_id", run.Id, "title", run.DisplayTitle)
+		return nil
+	}
+	build, err := repositories.GetBuildById(ctx, buildId)
+	if err != nil {
+		logger.WarnContext(ctx, "workflow run for unknown build", "run_id", run.Id, "build_id", buildId)
+		return nil
+	}
+	if build.Status != models.BuildStatusQueued {
+		return nil
+	}
+	build.WorkflowRunId = sql.NullInt64{Int64: run.Id, Valid: true}
+	build.LogsUrl = sql.NullString{String: run.HtmlUrl, Valid: run.HtmlUrl != ""}
+	if run.Conclusion == "success" {
+		build.ArtifactsUrl = sql.NullString{String: run.ArtifactsUrl, Valid: true}
+		recorded, err := repositories.RecordBuildRun(ctx, &build)
+		if recorded {
+			logger.InfoContext(ctx, "build run recorded for mirroring", "build_id", build.Id, "run_id", run.Id)
+		}
+		return err
+	}
+	build.Status = models.BuildStatusFailed
+	build.Error = sql.NullString{String: "workflow concluded " + run.Conclusion, Valid: true}
+	return s.complete(ctx, &build, nil, "")
+}
+
+// MirrorPending tải APK của các run thành công về storage. Lỗi được thử lại với backoff,
+// hết MaxMirrorAttempts lượt thì build bị đánh dấu failed
+func (s *BuildService) MirrorPending(ctx context.Context) error {
+	for ctx.Err() == nil {
+		builds, err := repositories.ClaimMirrorBuilds(ctx, mirrorBatchSize, mirrorLease)
+		if err != nil {
+			return err
+		}
+		if len(builds) == 0 {
+			return nil
+		}
+		for _, build := range builds {
+			if err := s.mirror(ctx, &build); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+const (
+	mirrorBatchSize = 5
+	// mirrorLease phải dài hơn thời gian tải và kiểm tra một artifact lớn nhất
+	mirrorLease = 15 * time.Minute
+)
+
+func (s *BuildService) mirror(ctx context.Context, build *models.Build) error {
+	version, err := s.storeApk(ctx, build, build.ArtifactsUrl.String)
+	if err == nil {
+		build.Status = models.BuildStatusSucceeded
+		build.Error = sql.NullString{}
+		return s.complete(ctx, build, version, s.Versions.DownloadUrl(build.AppId))
+	}
+	if ctx.Err() != nil {
+		// Worker đang dừng: build được nhận lại sau lease
+		return ctx.Err()
+	}
+	logger.WarnContext(ctx, "mirroring build artifact failed", "build_id", build.Id, "attempt", build.MirrorAttempts, "error", err)
+	if build.MirrorAttempts < s.MaxMirrorAttempts {
+		return repositories.RetryBuildMirror(ctx, build.Id, time.Now().Add(mirrorBackoff(build.MirrorAttempts)), err.Error())
+	}
+	build.Status = models.BuildStatusFailed
+	build.Error = sql.NullString{String: err.Error(), Valid: true}
+	return s.complete(ctx, build, nil, "")
+}
+
+// mirrorBackoff: 1m, 2m, 4m... tối đa 1 giờ
+func mirrorBackoff(attempts int) time.Duration {
+	d := time.Minute << max(attempts-1, 0)
+	if d > time.Hour || d <= 0 {
+		return time.Hour
+	}
+	return d
+}
+
+// complete ghi kết quả build và đếm metric; build đã có kết quả từ trước thì bỏ qua
+func (s *BuildService) complete(ctx context.Context, build *models.Build, version *models.AppVersion, installUri string) error {
+	completed, err := repositories.CompleteBuild(ctx, build, version, installUri)
+	if err != nil && version != nil && err.Error() == configs.GetErrString(configs.ErrorCode_APK_PACKAGE_CONFLICT) {
+		// Publisher khác vừa nhận package này sau bước kiểm tra trong Ingest
+		build.Status = models.BuildStatusFailed
+		build.Error = sql.NullString{String: err.Error(), Valid: true}
+		completed, err = repositories.CompleteBuild(ctx, build, nil, "")
+	}
+	if err != nil {
+		return err
+	}
+	if completed {
+		if build.Status == models.BuildStatusSucceeded {
+			configs.BuildDispatchTotal.WithLabelValues("build_succeeded").Inc()
+		} else {
+			configs.BuildDispatchTotal.WithLabelValues("build_failed").Inc()
+		}
+	}
+	return nil
+}
+
+// storeApk lấy artifact của run, kiểm tra APK và lưu vào storage
+func (s *BuildService) storeApk(ctx context.Context, build *models.Build, artifactsUrl string) (*models.AppVersion, error) {
+	archiveUrl, err := s.fetchArtifactUrl(ctx, artifactsUrl)
+	if err != nil {
+		return nil, fmt.Errorf("fetching artifact: %w", err)
+	}
+	build.ArtifactUrl = sql.NullString{String: archiveUrl, Valid: true}
+	app, err := repositories.GetAppById(ctx, build.AppId)
+	if err != nil {
+		return nil, err
+	}
+	apk, size, err := s.downloadArtifactApk(ctx, archiveUrl)
+	if err != nil {
+		return nil, fmt.Errorf("mirroring artifact: %w", err)
+	}
+	defer os.Remove(apk.Name())
+	defer apk.Close()
+	version, err := s.Versions.Ingest(ctx, app, apk, size)
+	if err != nil {
+		return nil, err
+	}
+	version.Source = models.AppVersionSourceBuild
+	version.BuildId = sql.NullString{String: build.Id, Valid: true}
+	return &version, nil
+}
+
+// fetchArtifactUrl trả về archive_download_url của artifact đầu tiên trong run
+func (s *BuildService) fetchArtifactUrl(ctx context.Context, artifactsUrl string) (string, error) {
+	if artifactsUrl == "" {
+		return "", errors.New("missing artifacts_url")
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", artifactsUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("github responded %d", resp.StatusCode)
+	}
+	var body struct {
+		Artifacts []struct {
+			ArchiveDownloadUrl string `json:"archive_download_url"`
+			Expired            bool   `json:"expired"`
+		} `json:"artifacts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	for _, a := range body.Artifacts {
+		if !a.Expired && a.ArchiveDownloadUrl != "" {
+			return a.ArchiveDownloadUrl, nil
+		}
+	}
+	return "", errors.New("run has no artifacts")
+}
+
+// FailStale đánh dấu failed các build không nhận được webhook sau Timeout
+func (s *BuildService) FailStale(ctx context.Context) error {
+	builds, err := repositories.GetStaleBuilds(ctx, s.Timeout)
+	if err != nil {
+		return err
+	}
+	for _, build := range builds {
+		build.Status = models.BuildStatusFailed
+		build.Error = sql.NullString{String: "no workflow_run webhook received in " + s.Timeout.String(), Valid: true}
+		completed, err := repositories.CompleteBuild(ctx, &build, nil, "")
+		if err != nil {
+			return err
+		}
+		if completed {
+			configs.BuildDispatchTotal.WithLabelValues("build_timeout").Inc()
+		}
+	}
+	return nil
+}
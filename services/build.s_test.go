@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestVerifyGitHubSignature(t *testing.T) {
+	body := []byte(`{"action":"completed"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	valid := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	cases := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"valid", "secret", body, valid, true},
+		{"wrong secret", "other", body, valid, false},
+		{"tampered body", "secret", []byte(`{"action":"requested"}`), valid, false},
+		{"bad signature", "secret", body, "sha256=" + hex.EncodeToString([]byte("nope")), false},
+		{"not hex", "secret", body, "sha256=zz", false},
+		{"missing prefix", "secret", body, valid[len("sha256="):], false},
+		{"empty signature", "secret", body, "", false},
+		{"no secret configured", "", body, valid, false},
+	}
+	for _, c := range cases {
+		if got := VerifyGitHubSignature(c.secret, c.body, c.signature); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestWorkflowRunBuildId(t *testing.T) {
+	cases := []struct {
+		title string
+		want  string
+	}{
+		{"build-apk 3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b", "3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b"},
+		{"build-apk 3F2A9C1E-5B7D-4E8F-9A0B-1C2D3E4F5A6B", "3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b"},
+		{"3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b (retry)", "3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b"},
+		{"build-apk", ""},
+		{"build-apk 3f2a9c1e-5b7d", ""},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := workflowRunBuildId(GitHubWorkflowRun{DisplayTitle: c.title}); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.title, got, c.want)
+		}
+	}
+}
+
+// Các run không khớp build phải bị bỏ qua trước khi chạm tới DB
+func TestHandleWorkflowRunIgnoresUnmatchedRuns(t *testing.T) {
+	s := &BuildService{}
+	title := "build-apk 3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b"
+	cases := map[string]GitHubWorkflowRunEvent{
+		"in progress":   {Action: "in_progress", WorkflowRun: GitHubWorkflowRun{Event: "repository_dispatch", DisplayTitle: title}},
+		"push run":      {Action: "completed", WorkflowRun: GitHubWorkflowRun{Event: "push", DisplayTitle: title}},
+		"no build id":   {Action: "completed", WorkflowRun: GitHubWorkflowRun{Event: "repository_dispatch", DisplayTitle: "build-apk"}},
+		"empty payload": {},
+	}
+	for name, event := range cases {
+		if err := s.HandleWorkflowRun(context.Background(), event); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestMirrorBackoff(t *testing.T) {
+	cases := map[int]time.Duration{0: time.Minute, 1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 7: time.Hour, 60: time.Hour}
+	for attempts, want := range cases {
+		if got := mirrorBackoff(attempts); got != want {
+			t.Errorf("attempts %d: got %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
+	"golang.org/x/text/unicode/norm"
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+const maxSlugLength = 50
+
+// NormalizeSlug đưa chuỗi về dạng slug: chữ thường, bỏ dấu tiếng Việt,
+// khoảng trắng và "_" thành "-". Trả về false nếu kết quả không phải slug hợp lệ.
+func NormalizeSlug(s string) (string, bool) {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(strings.ToLower(strings.TrimSpace(s))) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			r = 'd'
+		case unicode.IsSpace(r) || r == '_' || r == '-':
+			dash = b.Len() > 0
+			continue
+		}
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		b.WriteRune(r)
+	}
+	slug := b.String()
+	return slug, len(slug) <= maxSlugLength && slugPattern.MatchString(slug)
+}
+
+// CategoryInput là body tạo/sửa category; field nil thì giữ nguyên khi sửa,
+// parent_id rỗng để bỏ category cha
+type CategoryInput struct {
+	Slug     *string              `json:"slug,omitempty"`
+	Names    models.LocalizedText `json:"names,omitempty"`
+	Icon     *string              `json:"icon,omitempty"`
+	Position *int                 `json:"position,omitempty"`
+	ParentId *string              `json:"parent_id,omitempty"`
+}
+
+// TagInput là body tạo/sửa tag; tạo mới không có slug thì slug sinh từ name
+type TagInput struct {
+	Slug *string `json:"slug,omitempty"`
+	Name *string `json:"name,omitempty"`
+}
+
+type CatalogService struct{}
+
+func NewCatalogService() *CatalogService {
+	return &CatalogService{}
+}
+
+// GetCategories trả về category kèm số app, name theo locale
+func (s *CatalogService) GetCategories(ctx context.Context, locale string) ([]models.Category, error) {
+	categories, err := repositories.GetCategories(ctx)
+	for i := range categories {
+		categories[i].Name = categories[i].Names.Get(locale)
+	}
+	return categories, err
+}
+
+func (s *CatalogService) CreateCategory(ctx context.Context, input CategoryInput) (models.Category, error) {
+	category := models.Category{}
+	if err := s.applyCategoryInput(ctx, &category, input); err != nil {
+		return category, err
+	}
+	if err := repositories.CreateCategory(ctx, &category); err != nil {
+		return category, err
+	}
+	category.Name = category.Names.Get("")
+	return category, nil
+}
+
+func (s *CatalogService) UpdateCategory(ctx context.Context, id string, input CategoryInput) (models.Category, error) {
+	category, err := repositories.GetCategoryById(ctx, id)
+	if err != nil {
+		return category, err
+	}
+	if err := s.applyCategoryInput(ctx, &category, input); err != nil {
+		return category, err
+	}
+	if err := repositories.UpdateCategory(ctx, &category); err != nil {
+		return category, err
+	}
+	category.Name = category.Names.Get("")
+	return category, nil
+}
+
+func (s *CatalogService) DeleteCategory(ctx context.Context, id string) error {
+	return repositories.DeleteCategory(ctx, id)
+}
+
+// applyCategoryInput kiểm tra và gán input vào category. Chỉ cho phép hai cấp:
+// category cha phải là category gốc và category đã có con thì không thể có cha.
+func (s *CatalogService) applyCategoryInput(ctx context.Context, category *models.Category, input CategoryInput) error {
+	invalid := errors.New(configs.GetErrString(configs.ErrorCode_INVALID_CATEGORY))
+	if input.Slug != nil {
+		slug, ok := NormalizeSlug(*input.Slug)
+		if !ok {
+			return invalid
+		}
+		category.Slug = slug
+	}
+	if input.Names != nil {
+		names := models.LocalizedText{}
+		for locale, name := range input.Names {
+			if locale = strings.TrimSpace(locale); locale != "" && strings.TrimSpace(name) != "" {
+				names[locale] = strings.TrimSpace(name)
+			}
+		}
+		category.Names = names
+	}
+	if input.Icon != nil {
+		if *input.Icon != "" {
+			u, err := url.Parse(*input.Icon)
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return invalid
+			}
+		}
+		category.Icon = *input.Icon
+	}
+	if input.Position != nil {
+		category.Position = *input.Position
+	}
+	if category.Slug == "" || len(category.Names) == 0 {
+		return invalid
+	}
+	if input.ParentId == nil {
+		return nil
+	}
+	if *input.ParentId == "" {
+		category.ParentId = sql.NullString{}
+		return nil
+	}
+	if *input.ParentId == category.Id {
+		return invalid
+	}
+	categories, err := repositories.GetCategories(ctx)
+	if err != nil {
+		return err
+	}
+	var parent *models.Category
+	for i, c := range categories {
+		if c.Id == *input.ParentId {
+			parent = &categories[i]
+		}
+		if category.Id != "" && c.ParentId.String == category.Id {
+			return invalid
+		}
+	}
+	if parent == nil {
+		return errors.New(configs.GetErrString(configs.ErrorCode_CATEGORY_NOT_FOUND))
+	}
+	if parent.ParentId.Valid {
+		return invalid
+	}
+	category.ParentId = sql.NullString{String: parent.Id, Valid: true}
+	return nil
+}
+
+func (s *CatalogService) GetTags(ctx context.Context) ([]models.Tag, error) {
+	return repositories.GetTags(ctx)
+}
+
+func (s *CatalogService) CreateTag(ctx context.Context, input TagInput) (models.Tag, error) {
+	tag := models.Tag{}
+	if input.Slug == nil {
+		input.Slug = input.Name
+	}
+	if err := applyTagInput(&tag, input); err != nil {
+		return tag, err
+	}
+	err := repositories.CreateTag(ctx, &tag)
+	return tag, err
+}
+
+func (s *CatalogService) UpdateTag(ctx context.Context, id string, input TagInput) (models.Tag, error) {
+	tag, err := repositories.GetTagById(ctx, id)
+	if err != nil {
+		return tag, err
+	}
+	if err := applyTagInput(&tag, input); err != nil {
+		return tag, err
+	}
+	err = repositories.UpdateTag(ctx, &tag)
+	return tag, err
+}
+
+func (s *CatalogService) DeleteTag(ctx context.Context, id string) error {
+	return repositories.DeleteTag(ctx, id)
+}
+
+func applyTagInput(tag *models.Tag, input TagInput) error {
+	invalid := errors.New(configs.GetErrString(configs.ErrorCode_INVALID_TAG))
+	if input.Slug != nil {
+		slug, ok := NormalizeSlug(*input.Slug)
+		if !ok {
+			return invalid
+		}
+		tag.Slug = slug
+	}
+	if input.Name != nil {
+		tag.Name = strings.TrimSpace(*input.Name)
+	}
+	if tag.Name == "" || tag.Slug == "" {
+		return invalid
+	}
+	return nil
+}
+
+// NormalizeCategory chuyển category của app về slug và kiểm tra category tồn tại
+func (s *CatalogService) NormalizeCategory(ctx context.Context, category string) (string, error) {
+	if strings.TrimSpace(category) == "" {
+		return "", nil
+	}
+	slug, ok := NormalizeSlug(category)
+	if !ok {
+		return "", errors.New(configs.GetErrString(configs.ErrorCode_INVALID_CATEGORY))
+	}
+	if _, err := repositories.GetCategoryBySlug(ctx, slug); err != nil {
+		return "", fmt.Errorf("%s: %s", err.Error(), slug)
+	}
+	return slug, nil
+}
+
+// NormalizeTags chuyển tag của app về slug (bỏ trùng) và kiểm tra mọi tag có trong bộ tag
+func (s *CatalogService) NormalizeTags(ctx context.Context, tags []string) (pq.StringArray, error) {
+	slugs := pq.StringArray{}
+	for _, tag := range tags {
+		slug, ok := NormalizeSlug(tag)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s", configs.GetErrString(configs.ErrorCode_INVALID_TAG), tag)
+		}
+		if !containsString(slugs, slug) {
+			slugs = append(slugs, slug)
+		}
+	}
+	if len(slugs) == 0 {
+		return slugs, nil
+	}
+	missing, err := repositories.GetMissingTags(ctx, slugs)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s: %s", configs.GetErrString(configs.ErrorCode_TAG_NOT_FOUND), strings.Join(missing, ", "))
+	}
+	return slugs, nil
+}
@@ -0,0 +1,24 @@
+package services
+
+import "testing"
+
+func TestNormalizeSlug(t *testing.T) {
+	cases := map[string]struct {
+		slug string
+		ok   bool
+	}{
+		"Games":           {"games", true},
+		"  gaming ":       {"gaming", true},
+		"Photo & Video":   {"photo-&-video", false},
+		"Trò chơi":        {"tro-choi", true},
+		"Đồ hoạ_3D":       {"do-hoa-3d", true},
+		"multi   space--": {"multi-space", true},
+		"":                {"", false},
+	}
+	for in, want := range cases {
+		slug, ok := NormalizeSlug(in)
+		if slug != want.slug || ok != want.ok {
+			t.Errorf("NormalizeSlug(%q) = %q, %v; want %q, %v", in, slug, ok, want.slug, want.ok)
+		}
+	}
+}
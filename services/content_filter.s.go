@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// ContentVerdict là kết luận của bộ lọc nội dung cho một đoạn văn bản
+type ContentVerdict int
+
+const (
+	ContentAllow ContentVerdict = iota
+	// Lưu nhưng giữ lại chờ admin kiểm duyệt
+	ContentHold
+	// Từ chối ngay khi gửi
+	ContentReject
+)
+
+// ContentFilter kiểm tra văn bản do user gửi lên; reason dùng cho log
+type ContentFilter interface {
+	Check(ctx context.Context, text string) (verdict ContentVerdict, reason string)
+}
+
+// BannedWordFilter chặn văn bản chứa một trong các từ/cụm từ cấm (không phân biệt hoa thường,
+// so khớp theo nguyên từ nên "class" không khớp với từ cấm "ass")
+type BannedWordFilter struct {
+	Verdict ContentVerdict
+	phrases [][]string
+}
+
+func NewBannedWordFilter(verdict ContentVerdict, words ...string) *BannedWordFilter {
+	f := &BannedWordFilter{Verdict: verdict}
+	for _, w := range words {
+		if tokens := contentTokens(w); len(tokens) > 0 {
+			f.phrases = append(f.phrases, tokens)
+		}
+	}
+	return f
+}
+
+func (f *BannedWordFilter) Check(ctx context.Context, text string) (ContentVerdict, string) {
+	tokens := contentTokens(text)
+	for _, phrase := range f.phrases {
+		for i := 0; i+len(phrase) <= len(tokens); i++ {
+			match := true
+			for j, t := range phrase {
+				if tokens[i+j] != t {
+					match = false
+					break
+				}
+			}
+			if match {
+				return f.Verdict, "banned word: " + strings.Join(phrase, " ")
+			}
+		}
+	}
+	return ContentAllow, ""
+}
+
+func contentTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// checkContent chạy lần lượt các bộ lọc và trả về kết luận nặng nhất
+func checkContent(ctx context.Context, filters []ContentFilter, text string) ContentVerdict {
+	result := ContentAllow
+	for _, f := range filters {
+		verdict, reason := f.Check(ctx, text)
+		if verdict > result {
+			logger.InfoContext(ctx, "content flagged", "verdict", verdict, "reason", reason)
+			result = verdict
+		}
+	}
+	return result
+}
+
+// defaultContentFilters dựng bộ lọc từ cấm từ CONTENT_FILTER_BANNED_WORDS (phân cách bằng dấu phẩy)
+// và file CONTENT_FILTER_BANNED_WORDS_FILE (mỗi dòng một từ, # là chú thích).
+// CONTENT_FILTER_ACTION = hold (mặc định) | reject.
+func defaultContentFilters() []ContentFilter {
+	words := strings.Split(os.Getenv("CONTENT_FILTER_BANNED_WORDS"), ",")
+	if path := os.Getenv("CONTENT_FILTER_BANNED_WORDS_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			logger.Error("cannot read banned word list", "path", path, "error", err)
+		} else {
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+					words = append(words, line)
+				}
+			}
+			file.Close()
+		}
+	}
+	verdict := ContentHold
+	if os.Getenv("CONTENT_FILTER_ACTION") == "reject" {
+		verdict = ContentReject
+	}
+	filter := NewBannedWordFilter(verdict, words...)
+	if len(filter.phrases) == 0 {
+		return nil
+	}
+	return []ContentFilter{filter}
+}
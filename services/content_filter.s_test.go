@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"waheim.api/configs"
+)
+
+func TestBannedWordFilter(t *testing.T) {
+	f := NewBannedWordFilter(ContentHold, "scam", "Free Money", " ")
+	cases := map[string]ContentVerdict{
+		"Great app, works offline":     ContentAllow,
+		"This is a SCAM!":              ContentHold,
+		"scammer app":                  ContentAllow,
+		"get free   money here":        ContentHold,
+		"free apps, money well spent":  ContentAllow,
+		"Ứng dụng tốt, scam? không hề": ContentHold,
+	}
+	for text, want := range cases {
+		if got, _ := f.Check(context.Background(), text); got != want {
+			t.Errorf("%q: got %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestPostRatingRejectedComment(t *testing.T) {
+	s := &RatingService{Filters: []ContentFilter{
+		NewBannedWordFilter(ContentHold, "meh"),
+		NewBannedWordFilter(ContentReject, "scam"),
+	}}
+	_, err := s.PostRating(context.Background(), "user", "app", 1, "meh, total scam")
+	if err == nil || !strings.HasPrefix(err.Error(), configs.GetErrString(configs.ErrorCode_RATING_COMMENT_REJECTED)) {
+		t.Fatalf("expected comment rejected, got %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+// SiteMetadata là thông tin đọc được từ trang chủ của web app
+type SiteMetadata struct {
+	Url         string
+	Title       string
+	Description string
+	Icon        string
+	ThemeColor  string
+	ManifestUrl string
+	Manifest    *SiteManifest
+}
+
+// SiteManifest là phần cần dùng của web manifest mà site tự khai báo
+type SiteManifest struct {
+	Name            string           `json:"name"`
+	ShortName       string           `json:"short_name"`
+	Description     string           `json:"description"`
+	ThemeColor      string           `json:"theme_color"`
+	BackgroundColor string           `json:"background_color"`
+	Icons           []models.AppIcon `json:"icons"`
+	Categories      []string         `json:"categories"`
+}
+
+// Crawler tải trang của web app bằng SafeClient, giới hạn kích thước response
+type Crawler struct {
+	Client          *http.Client
+	MaxPageSize     int64
+	MaxManifestSize int64
+}
+
+func NewCrawler() *Crawler {
+	return &Crawler{Client: configs.SafeClient, MaxPageSize: 1 << 20, MaxManifestSize: 256 << 10}
+}
+
+// Inspect kiểm tra URI là trang HTTPS truy cập được và đọc title, description,
+// icon, theme color cùng web manifest (nếu có)
+func (c *Crawler) Inspect(ctx context.Context, rawUrl string) (SiteMetadata, error) {
+	meta := SiteMetadata{Url: rawUrl}
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return meta, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_APP_URI))
+	}
+	resp, err := c.get(ctx, u.String(), "text/html")
+	if err != nil {
+		return meta, unreachable(err)
+	}
+	defer resp.Body.Close()
+	if resp.Request.URL.Scheme != "https" {
+		return meta, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_APP_URI))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return meta, unreachable(fmt.Errorf("responded %d", resp.StatusCode))
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return meta, unreachable(fmt.Errorf("not an HTML page (%s)", mediaType))
+	}
+	page := resp.Request.URL
+
+	var touchIcon, icon string
+	parseHead(io.LimitReader(resp.Body, c.MaxPageSize), func(tag string, attrs map[string]string, text string) {
+		switch tag {
+		case "title":
+			if meta.Title == "" {
+				meta.Title = strings.TrimSpace(text)
+			}
+		case "meta":
+			switch strings.ToLower(attrs["name"]) {
+			case "description":
+				meta.Description = strings.TrimSpace(attrs["content"])
+			case "theme-color":
+				meta.ThemeColor = strings.TrimSpace(attrs["content"])
+			}
+		case "link":
+			href := resolveUrl(page, attrs["href"])
+			if href == "" {
+				return
+			}
+			rels := strings.Fields(strings.ToLower(attrs["rel"]))
+			switch {
+			case containsString(rels, "manifest"):
+				meta.ManifestUrl = href
+			case containsString(rels, "apple-touch-icon") && touchIcon == "":
+				touchIcon = href
+			case containsString(rels, "icon") && icon == "":
+				icon = href
+			}
+		}
+	})
+
+	if meta.ManifestUrl != "" {
+		manifest, err := c.fetchManifest(ctx, meta.ManifestUrl)
+		if err != nil {
+			logger.InfoContext(ctx, "web manifest unavailable", "url", meta.ManifestUrl, "error", err)
+		} else {
+			meta.Manifest = manifest
+		}
+	}
+	meta.Icon = largestIcon(meta.Manifest)
+	if meta.Icon == "" {
+		meta.Icon = touchIcon
+	}
+	if meta.Icon == "" {
+		meta.Icon = icon
+	}
+	return meta, nil
+}
+
+func (c *Crawler) get(ctx context.Context, rawUrl, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", "WaheimBot/1.0 (+https://thinhphoenix.github.io)")
+	return c.Client.Do(req)
+}
+
+func (c *Crawler) fetchManifest(ctx context.Context, manifestUrl string) (*SiteManifest, error) {
+	resp, err := c.get(ctx, manifestUrl, "application/manifest+json, application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("responded %d", resp.StatusCode)
+	}
+	var manifest SiteManifest
+	if err := json.NewDecoder(io.LimitReader(resp.Body, c.MaxManifestSize)).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	// src của icon tương đối theo URL của manifest
+	base := resp.Request.URL
+	icons := manifest.Icons[:0]
+	for _, i := range manifest.Icons {
+		if i.Src = resolveUrl(base, i.Src); i.Src != "" {
+			icons = append(icons, i)
+		}
+	}
+	manifest.Icons = icons
+	return &manifest, nil
+}
+
+// parseHead đọc các thẻ title, meta, link cho tới hết <head>
+func parseHead(r io.Reader, visit func(tag string, attrs map[string]string, text string)) {
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if tag == "body" {
+				return
+			}
+			attrs := map[string]string{}
+			for hasAttr {
+				var k, v []byte
+				k, v, hasAttr = z.TagAttr()
+				attrs[string(k)] = string(v)
+			}
+			text := ""
+			if tag == "title" && z.Next() == html.TextToken {
+				text = string(z.Text())
+			}
+			visit(tag, attrs, text)
+		}
+	}
+}
+
+// resolveUrl trả về URL tuyệt đối http(s), hoặc rỗng nếu không hợp lệ
+func resolveUrl(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return ""
+	}
+	return u.String()
+}
+
+// largestIcon chọn icon có kích thước lớn nhất trong manifest
+func largestIcon(m *SiteManifest) string {
+	if m == nil {
+		return ""
+	}
+	best, bestSize := "", -1
+	for _, icon := range m.Icons {
+		size := 0
+		for _, s := range strings.Fields(icon.Sizes) {
+			if s == "any" {
+				size = 1 << 20
+				break
+			}
+			if w, _, ok := strings.Cut(s, "x"); ok {
+				if n, err := strconv.Atoi(w); err == nil && n > size {
+					size = n
+				}
+			}
+		}
+		if size > bestSize {
+			best, bestSize = icon.Src, size
+		}
+	}
+	return best
+}
+
+func unreachable(err error) error {
+	if errors.Is(err, configs.ErrPrivateAddress) {
+		return errors.New(configs.GetErrString(configs.ErrorCode_INVALID_APP_URI))
+	}
+	return fmt.Errorf("%s: %v", configs.GetErrString(configs.ErrorCode_APP_URI_UNREACHABLE), err)
+}
+
+// applySiteMetadata điền các trường còn trống của app từ metadata của site
+func applySiteMetadata(app *models.App, meta SiteMetadata) {
+	m := meta.Manifest
+	if m == nil {
+		m = &SiteManifest{}
+	}
+	fill := func(field *string, candidates ...string) {
+		for _, c := range candidates {
+			if *field == "" && strings.TrimSpace(c) != "" {
+				*field = strings.TrimSpace(c)
+			}
+		}
+	}
+	fill(&app.Name, m.Name, meta.Title, m.ShortName)
+	fill(&app.Description, meta.Description, m.Description)
+	fill(&app.Icon, meta.Icon)
+	if len(app.Icons) == 0 && validateAppTheme("", "", m.Icons) == nil {
+		app.Icons = m.Icons
+	}
+	for _, c := range []string{m.ThemeColor, meta.ThemeColor} {
+		if app.ThemeColor == "" && hexColorPattern.MatchString(c) {
+			app.ThemeColor = c
+		}
+	}
+	if app.BackgroundColor == "" && hexColorPattern.MatchString(m.BackgroundColor) {
+		app.BackgroundColor = m.BackgroundColor
+	}
+	if app.Category == "" && len(m.Categories) > 0 {
+		app.Category = m.Categories[0]
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+func TestCrawlerInspectPrefillsApp(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<!doctype html><html><head>
+<title> Notes </title>
+<meta name="description" content="Take notes offline">
+<meta name="theme-color" content="#112233">
+<link rel="icon" href="/favicon.ico">
+<link rel="manifest" href="/static/app.webmanifest">
+</head><body><title>ignored</title></body></html>`))
+	})
+	mux.HandleFunc("/static/app.webmanifest", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/manifest+json")
+		w.Write([]byte(`{"name":"Notes App","background_color":"#000000","categories":["productivity"],
+"icons":[{"src":"icon-192.png","sizes":"192x192"},{"src":"/icon-512.png","sizes":"512x512"}]}`))
+	})
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	c := &Crawler{Client: srv.Client(), MaxPageSize: 1 << 20, MaxManifestSize: 1 << 16}
+	meta, err := c.Inspect(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "Notes" || meta.Description != "Take notes offline" || meta.ThemeColor != "#112233" {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+	if meta.Icon != srv.URL+"/icon-512.png" {
+		t.Fatalf("icon = %q", meta.Icon)
+	}
+
+	app := models.App{Uri: srv.URL + "/", Description: "Mine"}
+	applySiteMetadata(&app, meta)
+	if app.Name != "Notes App" || app.Description != "Mine" || app.Category != "productivity" {
+		t.Fatalf("unexpected app %+v", app)
+	}
+	if app.BackgroundColor != "#000000" || app.ThemeColor != "#112233" || len(app.Icons) != 2 {
+		t.Fatalf("unexpected theme %+v", app)
+	}
+	if app.Icons[0].Src != srv.URL+"/static/icon-192.png" {
+		t.Fatalf("icon src = %q", app.Icons[0].Src)
+	}
+}
+
+func TestCrawlerRejectsInvalidUri(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	c := &Crawler{Client: srv.Client(), MaxPageSize: 1 << 20}
+
+	cases := map[string]configs.ErrorCode{
+		"http://example.com/":  configs.ErrorCode_INVALID_APP_URI,
+		"ftp://example.com/":   configs.ErrorCode_INVALID_APP_URI,
+		"https://":             configs.ErrorCode_INVALID_APP_URI,
+		srv.URL + "/not-found": configs.ErrorCode_APP_URI_UNREACHABLE,
+	}
+	for uri, code := range cases {
+		_, err := c.Inspect(context.Background(), uri)
+		if err == nil || !strings.HasPrefix(err.Error(), configs.GetErrString(code)) {
+			t.Errorf("%s: got %v, want %s", uri, err, configs.GetErrString(code))
+		}
+	}
+}
+
+func TestSafeClientBlocksPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	_, err := configs.NewSafeClient(time.Second, false).Get(srv.URL)
+	if !errors.Is(err, configs.ErrPrivateAddress) {
+		t.Fatalf("expected private address error, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+// EventSink nhận domain event từ outbox relay. Delivery là at-least-once,
+// sink phải idempotent theo Event.Id.
+type EventSink interface {
+	Name() string
+	Deliver(ctx context.Context, event models.Event) error
+}
+
+type EventHandler func(ctx context.Context, event models.Event) error
+
+// EventBus là sink in-process: các phần khác của hệ thống subscribe theo loại event
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+// Events là bus dùng chung, relay luôn gửi event vào đây
+var Events = NewEventBus()
+
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: map[string][]EventHandler{}}
+}
+
+// Subscribe đăng ký handler cho một loại event, "*" để nhận mọi event
+func (b *EventBus) Subscribe(eventType string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *EventBus) Name() string {
+	return "bus"
+}
+
+func (b *EventBus) Deliver(ctx context.Context, event models.Event) error {
+	b.mu.RLock()
+	handlers := append(append([]EventHandler{}, b.handlers[event.Type]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+	var errs []error
+	for _, h := range handlers {
+		if err := h(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HTTPEventSink POST event dạng JSON tới một endpoint cố định
+type HTTPEventSink struct {
+	Url    string
+	Client *http.Client
+}
+
+func (s *HTTPEventSink) Name() string {
+	return "http"
+}
+
+func (s *HTTPEventSink) Deliver(ctx context.Context, event models.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", event.Id)
+	req.Header.Set("X-Event-Type", event.Type)
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("http sink responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// OutboxRelay chuyển event từ bảng outbox tới các sink, retry với backoff luỹ thừa
+type OutboxRelay struct {
+	Sinks     []EventSink
+	BatchSize int
+}
+
+// NewOutboxRelay luôn gửi vào Events; thêm HTTP sink nếu đặt EVENTS_HTTP_SINK_URL
+func NewOutboxRelay() *OutboxRelay {
+	relay := &OutboxRelay{Sinks: []EventSink{Events}, BatchSize: 50}
+	if url := os.Getenv("EVENTS_HTTP_SINK_URL"); url != "" {
+		relay.Sinks = append(relay.Sinks, &HTTPEventSink{Url: url, Client: configs.OutboundClient})
+	}
+	return relay
+}
+
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		events, err := repositories.ClaimOutboxEvents(ctx, r.BatchSize, time.Minute)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		for _, event := range events {
+			r.deliver(ctx, event)
+		}
+	}
+	return ctx.Err()
+}
+
+func (r *OutboxRelay) deliver(ctx context.Context, event models.Event) {
+	var errs []error
+	for _, sink := range r.Sinks {
+		if err := sink.Deliver(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		retry := eventBackoff(event.Attempts)
+		logger.WarnContext(ctx, "event delivery failed", "event_id", event.Id, "type", event.Type, "attempt", event.Attempts+1, "retry_in", retry.String(), "error", err)
+		repositories.MarkEventFailed(ctx, event.Id, err.Error(), retry)
+		return
+	}
+	repositories.MarkEventDelivered(ctx, event.Id)
+}
+
+// eventBackoff: 10s, 20s, 40s... tối đa 1 giờ
+func eventBackoff(attempts int) time.Duration {
+	d := 10 * time.Second * time.Duration(math.Pow(2, float64(attempts)))
+	if d > time.Hour || d <= 0 {
+		return time.Hour
+	}
+	return d
+}
+
+func init() {
+	Health.AddReadiness("outbox_queue", QueueCheck("outbox", "delivered_at IS NULL"))
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+// ExportService tạo bản sao dữ liệu cá nhân của user dưới dạng file zip.
+// Link tải được ký HMAC bằng EXPORT_SIGNING_SECRET (configs.ConfExport), hết hạn sau
+// EXPORT_LINK_TTL_HOURS và chỉ dùng được một lần. Export kẹt ở processing quá
+// EXPORT_PROCESSING_TIMEOUT_MINUTES được worker nhận lại.
+type ExportService struct {
+	linkTTL           time.Duration
+	processingTimeout time.Duration
+	baseUrl           string
+}
+
+func NewExportService() *ExportService {
+	return &ExportService{
+		linkTTL:           time.Duration(envInt("EXPORT_LINK_TTL_HOURS", 24)) * time.Hour,
+		processingTimeout: time.Duration(envInt("EXPORT_PROCESSING_TIMEOUT_MINUTES", 30)) * time.Minute,
+		baseUrl:           os.Getenv("PUBLIC_BASE_URL"),
+	}
+}
+
+func (s *ExportService) RequestExport(ctx context.Context, userId string) (models.DataExport, error) {
+	return repositories.CreateDataExport(ctx, userId, s.processingTimeout)
+}
+
+// GetExport trả về trạng thái export, kèm link tải đã ký nếu export sẵn sàng
+func (s *ExportService) GetExport(ctx context.Context, id, userId string) (models.DataExport, error) {
+	export, err := repositories.GetDataExport(ctx, id, userId)
+	if err != nil {
+		return export, err
+	}
+	if export.Status == "ready" && export.ExpiresAt.Valid && export.ExpiresAt.Time.After(time.Now()) {
+		expires := export.ExpiresAt.Time.Unix()
+		export.DownloadUrl = fmt.Sprintf("%s/exports/%s/download?expires=%d&sig=%s", s.baseUrl, export.Id, expires, s.sign(export.Id, expires))
+	}
+	return export, nil
+}
+
+// Download kiểm tra chữ ký và trả về archive. Export chỉ bị đánh dấu đã tải sau khi mở được
+// archive, để lỗi storage không làm mất link
+func (s *ExportService) Download(ctx context.Context, id, expiresStr, sig string) (*configs.Blob, error) {
+	invalid := errors.New(configs.GetErrString(configs.ErrorCode_EXPORT_LINK_INVALID))
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return nil, invalid
+	}
+	if len(configs.ExportSigningSecret) == 0 || !hmac.Equal([]byte(sig), []byte(s.sign(id, expires))) {
+		return nil, invalid
+	}
+	key, err := repositories.GetReadyDataExportKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := configs.Storage.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := repositories.ConsumeDataExport(ctx, id); err != nil {
+		blob.Close()
+		return nil, err
+	}
+	blob.ReadSeekCloser = &deleteOnClose{ReadSeekCloser: blob.ReadSeekCloser, key: key}
+	return blob, nil
+}
+
+// deleteOnClose xoá archive khỏi storage sau khi đã gửi xong cho user
+type deleteOnClose struct {
+	io.ReadSeekCloser
+	key string
+}
+
+func (d *deleteOnClose) Close() error {
+	err := d.ReadSeekCloser.Close()
+	configs.Storage.Delete(context.Background(), d.key)
+	return err
+}
+
+func (s *ExportService) sign(id string, expires int64) string {
+	mac := hmac.New(sha256.New, configs.ExportSigningSecret)
+	fmt.Fprintf(mac, "%s.%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ProcessPending xử lý lần lượt các export đang chờ và dọn các archive đã hết hạn
+func (s *ExportService) ProcessPending(ctx context.Context) error {
+	keys, err := repositories.ExpireDataExports(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		configs.Storage.Delete(ctx, key)
+	}
+	for ctx.Err() == nil {
+		export, err := repositories.ClaimPendingDataExport(ctx, s.processingTimeout)
+		if err != nil {
+			return err
+		}
+		if export == nil {
+			return nil
+		}
+		if err := s.build(ctx, export); err != nil {
+			logger.ErrorContext(ctx, "export failed", "export_id", export.Id, "error", err)
+			repositories.FailDataExport(ctx, export.Id, err.Error())
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *ExportService) build(ctx context.Context, export *models.DataExport) error {
+	user, err := repositories.GetUserById(ctx, export.UserId)
+	if err != nil {
+		return err
+	}
+	apps, err := repositories.GetAppsByPublisher(ctx, export.UserId)
+	if err != nil {
+		return err
+	}
+	ratings, err := repositories.GetRatingsByUser(ctx, export.UserId)
+	if err != nil {
+		return err
+	}
+	apiKeys, err := repositories.GetApiKeysByUser(ctx, export.UserId)
+	if err != nil {
+		return err
+	}
+	favorites, err := repositories.GetFavoritesByUser(ctx, export.UserId)
+	if err != nil {
+		return err
+	}
+	sessions, err := repositories.GetUserSessionsByUser(ctx, export.UserId)
+	if err != nil {
+		return err
+	}
+	installs, err := repositories.GetAppInstallsByUser(ctx, export.UserId)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = writeExportArchive(&buf, map[string]interface{}{
+		"user.json":      newExportUser(user),
+		"apps.json":      apps,
+		"ratings.json":   ratings,
+		"api_keys.json":  apiKeys,
+		"favorites.json": favorites,
+		"sessions.json":  sessions,
+		"installs.json":  installs,
+	})
+	if err != nil {
+		return err
+	}
+
+	key := "exports/" + export.Id + ".zip"
+	size, err := configs.Storage.Put(ctx, key, &buf)
+	if err != nil {
+		return err
+	}
+	configs.UploadSizeBytes.WithLabelValues("export").Observe(float64(size))
+	return repositories.CompleteDataExport(ctx, export.Id, key, size, time.Now().Add(s.linkTTL))
+}
+
+var exportFileNames = []string{"user.json", "apps.json", "ratings.json", "api_keys.json", "favorites.json", "sessions.json", "installs.json"}
+
+// writeExportArchive ghi các file JSON của export vào zip theo thứ tự cố định
+func writeExportArchive(w io.Writer, files map[string]interface{}) error {
+	zw := zip.NewWriter(w)
+	for _, name := range exportFileNames {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(files[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// exportUser là hồ sơ user trong export; không có trường thông tin đăng nhập (password hash)
+type exportUser struct {
+	Id          string         `json:"id"`
+	Username    string         `json:"username"`
+	Email       string         `json:"email"`
+	Phone       string         `json:"phone"`
+	Address     string         `json:"address"`
+	CreatedAt   string         `json:"created_at"`
+	UpdatedAt   string         `json:"updated_at"`
+	DeletedAt   sql.NullString `json:"deleted_at"`
+	IsActive    bool           `json:"is_active"`
+	Role        string         `json:"role"`
+	Avatar      sql.NullString `json:"avatar"`
+	FirstName   sql.NullString `json:"first_name"`
+	LastName    sql.NullString `json:"last_name"`
+	DateOfBirth sql.NullTime   `json:"date_of_birth"`
+	Gender      sql.NullString `json:"gender"`
+	Status      sql.NullString `json:"status"`
+}
+
+func newExportUser(u models.User) exportUser {
+	return exportUser{
+		Id:          u.Id,
+		Username:    u.Username,
+		Email:       u.Email,
+		Phone:       u.Phone,
+		Address:     u.Address,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		DeletedAt:   u.DeletedAt,
+		IsActive:    u.IsActive,
+		Role:        u.Role,
+		Avatar:      u.Avatar,
+		FirstName:   u.FirstName,
+		LastName:    u.LastName,
+		DateOfBirth: u.DateOfBirth,
+		Gender:      u.Gender,
+		Status:      u.Status,
+	}
+}
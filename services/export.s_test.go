@@ -0,0 +1,55 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"waheim.api/models"
+)
+
+func TestExportArchiveOmitsCredentials(t *testing.T) {
+	user := models.User{
+		Id:       "u1",
+		Username: "lan",
+		Email:    "lan@example.com",
+		Password: "$2a$10$hash",
+		Status:   sql.NullString{String: "active", Valid: true},
+	}
+	apiKeys := []models.ApiKey{{Id: "k1", Prefix: "whk_ab", SecretHash: "secret-hash"}}
+	var buf bytes.Buffer
+	if err := writeExportArchive(&buf, map[string]interface{}{"user.json": newExportUser(user), "api_keys.json": apiKeys}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != len(exportFileNames) {
+		t.Errorf("archive has %d files, want %d", len(zr.File), len(exportFileNames))
+	}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		raw, _ := io.ReadAll(rc)
+		rc.Close()
+		if bytes.Contains(raw, []byte("hash")) {
+			t.Errorf("%s leaks a credential hash: %s", f.Name, raw)
+		}
+		if f.Name != "user.json" {
+			continue
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(raw, &got); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := got["password"]; ok {
+			t.Error("user.json has a password key")
+		}
+		if got["email"] != "lan@example.com" || got["username"] != "lan" {
+			t.Errorf("user.json missing profile fields: %v", got)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+type FavoriteService struct{}
+
+func NewFavoriteService() *FavoriteService {
+	return &FavoriteService{}
+}
+
+func (s *FavoriteService) Add(ctx context.Context, userId, appId string) error {
+	return repositories.AddFavorite(ctx, userId, appId)
+}
+
+func (s *FavoriteService) Remove(ctx context.Context, userId, appId string) error {
+	return repositories.RemoveFavorite(ctx, userId, appId)
+}
+
+func (s *FavoriteService) GetFavorites(ctx context.Context, userId string, limit, offset int) ([]models.App, error) {
+	return repositories.GetFavoriteApps(ctx, userId, limit, offset)
+}
+
+func (s *FavoriteService) IsFavorite(ctx context.Context, userId, appId string) (bool, error) {
+	return repositories.IsFavorite(ctx, userId, appId)
+}
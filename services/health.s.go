@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/repositories"
+)
+
+type HealthCheckFunc func(ctx context.Context) error
+
+type HealthCheckResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks"`
+}
+
+// HealthRegistry giữ các checker cho liveness và readiness.
+// Các service tự đăng ký checker của mình trong init().
+type HealthRegistry struct {
+	mu           sync.RWMutex
+	liveness     map[string]HealthCheckFunc
+	readiness    map[string]HealthCheckFunc
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+var Health = NewHealthRegistry()
+
+func NewHealthRegistry() *HealthRegistry {
+	return &HealthRegistry{
+		liveness:  map[string]HealthCheckFunc{},
+		readiness: map[string]HealthCheckFunc{},
+		timeout:   2 * time.Second,
+	}
+}
+
+func (h *HealthRegistry) AddLiveness(name string, check HealthCheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness[name] = check
+}
+
+func (h *HealthRegistry) AddReadiness(name string, check HealthCheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness[name] = check
+}
+
+// SetShuttingDown làm readiness fail để load balancer ngừng gửi traffic trước khi tắt server
+func (h *HealthRegistry) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *HealthRegistry) Liveness(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.run(ctx, h.liveness)
+}
+
+func (h *HealthRegistry) Readiness(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	checks := make(map[string]HealthCheckFunc, len(h.readiness)+1)
+	for name, check := range h.readiness {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+	checks["shutdown"] = func(ctx context.Context) error {
+		if h.shuttingDown.Load() {
+			return fmt.Errorf("server is shutting down")
+		}
+		return nil
+	}
+	return h.run(ctx, checks)
+}
+
+// run chạy song song các checker, mỗi checker bị giới hạn bởi timeout
+func (h *HealthRegistry) run(ctx context.Context, checks map[string]HealthCheckFunc) HealthReport {
+	report := HealthReport{Status: "ok", Checks: map[string]HealthCheckResult{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check HealthCheckFunc) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+			start := time.Now()
+			errCh := make(chan error, 1)
+			go func() { errCh <- check(cctx) }()
+			var err error
+			select {
+			case err = <-errCh:
+			case <-cctx.Done():
+				err = cctx.Err()
+			}
+			result := HealthCheckResult{Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+			mu.Lock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = "fail"
+			}
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+	return report
+}
+
+// QueueCheck báo lỗi khi hàng đợi có quá nhiều job chờ hoặc job cũ nhất chờ quá lâu.
+// Ngưỡng đọc từ HEALTH_MAX_QUEUE_DEPTH (mặc định 100) và HEALTH_MAX_QUEUE_AGE_SECONDS (mặc định 600).
+func QueueCheck(table, pendingCondition string) HealthCheckFunc {
+	maxDepth := envInt("HEALTH_MAX_QUEUE_DEPTH", 100)
+	maxAge := envInt("HEALTH_MAX_QUEUE_AGE_SECONDS", 600)
+	return func(ctx context.Context) error {
+		depth, oldest, err := repositories.GetQueueDepth(ctx, table, pendingCondition)
+		if err != nil {
+			return err
+		}
+		if depth > maxDepth {
+			return fmt.Errorf("%d jobs pending (max %d)", depth, maxDepth)
+		}
+		if oldest > float64(maxAge) {
+			return fmt.Errorf("oldest job pending for %.0fs (max %ds)", oldest, maxAge)
+		}
+		return nil
+	}
+}
+
+func envInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+func init() {
+	Health.AddLiveness("process", func(ctx context.Context) error { return nil })
+	Health.AddReadiness("database", repositories.PingDB)
+	Health.AddReadiness("schema_version", func(ctx context.Context) error {
+		version, err := repositories.GetSchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version != configs.SchemaVersion {
+			return fmt.Errorf("schema version %d, expected %d", version, configs.SchemaVersion)
+		}
+		return nil
+	})
+	Health.AddReadiness("blob_store", func(ctx context.Context) error {
+		return configs.Storage.Ping(ctx)
+	})
+	Health.AddReadiness("export_queue", QueueCheck("data_exports", "status = 'pending'"))
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+const healthCheckRetention = 30 * 24 * time.Hour
+
+// MonitorService định kỳ probe Uri của các app đã published. Sau FailureThreshold
+// lần lỗi liên tiếp app bị đánh dấu unhealthy và publisher nhận event app.unhealthy
+// (qua webhook). Lịch sử probe giữ 30 ngày để tính uptime.
+type MonitorService struct {
+	Client           *http.Client
+	Interval         time.Duration
+	FailureThreshold int
+	BatchSize        int
+	Concurrency      int
+}
+
+// NewMonitorService đọc APP_MONITOR_INTERVAL_MINUTES (mặc định 10),
+// APP_MONITOR_FAILURES (mặc định 3) và APP_MONITOR_CONCURRENCY (mặc định 8)
+func NewMonitorService() *MonitorService {
+	return &MonitorService{
+		Client:           configs.SafeClient,
+		Interval:         time.Duration(envInt("APP_MONITOR_INTERVAL_MINUTES", 10)) * time.Minute,
+		FailureThreshold: envInt("APP_MONITOR_FAILURES", 3),
+		BatchSize:        50,
+		Concurrency:      envInt("APP_MONITOR_CONCURRENCY", 8),
+	}
+}
+
+// ProcessDue probe các app tới lượt, mỗi lần tối đa BatchSize app, chạy song song Concurrency probe
+func (s *MonitorService) ProcessDue(ctx context.Context) error {
+	for ctx.Err() == nil {
+		apps, err := repositories.ClaimAppsForHealthCheck(ctx, s.Interval, s.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(apps) == 0 {
+			return nil
+		}
+		sem := make(chan struct{}, s.Concurrency)
+		var wg sync.WaitGroup
+		for _, app := range apps {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(app models.App) {
+				defer func() { <-sem; wg.Done() }()
+				check := s.Probe(ctx, app)
+				if !check.Ok {
+					logger.WarnContext(ctx, "app health check failed", "app_id", app.Id, "uri", app.Uri,
+						"status_code", check.StatusCode, "error", check.Error.String)
+				}
+				if err := repositories.RecordAppHealthCheck(ctx, &check, s.FailureThreshold); err != nil {
+					logger.ErrorContext(ctx, "recording app health check failed", "app_id", app.Id, "error", err)
+				}
+			}(app)
+		}
+		wg.Wait()
+	}
+	return ctx.Err()
+}
+
+// Probe gửi GET tới Uri của app; app được coi là up nếu trả status < 400 sau redirect.
+// Kết quả là named result để defer ghi được ResponseMs vào giá trị trả về.
+func (s *MonitorService) Probe(ctx context.Context, app models.App) (check models.AppHealthCheck) {
+	check = models.AppHealthCheck{AppId: app.Id}
+	start := time.Now()
+	defer func() {
+		check.ResponseMs = time.Since(start).Milliseconds()
+		status := "down"
+		if check.Ok {
+			status = "up"
+		}
+		configs.AppHealthChecksTotal.WithLabelValues(status).Inc()
+	}()
+	req, err := http.NewRequestWithContext(ctx, "GET", app.Uri, nil)
+	if err != nil {
+		check.Error = sql.NullString{String: err.Error(), Valid: true}
+		return check
+	}
+	req.Header.Set("User-Agent", "WaheimBot/1.0 (+https://thinhphoenix.github.io)")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		check.Error = sql.NullString{String: err.Error(), Valid: true}
+		return check
+	}
+	defer resp.Body.Close()
+	// Đọc một phần body để thời gian phản hồi gồm cả lúc server bắt đầu trả nội dung
+	io.CopyN(io.Discard, resp.Body, 64<<10)
+	check.StatusCode = resp.StatusCode
+	check.Ok = resp.StatusCode < 400
+	if !check.Ok {
+		check.Error = sql.NullString{String: fmt.Sprintf("responded %d", resp.StatusCode), Valid: true}
+	}
+	return check
+}
+
+// Prune xoá lịch sử probe cũ hơn 30 ngày
+func (s *MonitorService) Prune(ctx context.Context) error {
+	n, err := repositories.PruneAppHealthChecks(ctx, healthCheckRetention)
+	if err == nil && n > 0 {
+		logger.InfoContext(ctx, "pruned app health checks", "count", n)
+	}
+	return err
+}
+
+// Uptime trả về trạng thái và uptime của app cho API chi tiết
+func (s *MonitorService) Uptime(ctx context.Context, app models.App) (models.AppUptime, error) {
+	uptime, err := repositories.GetAppUptime(ctx, app.Id)
+	uptime.Status = app.HealthStatus
+	uptime.LastCheckedAt = app.HealthCheckedAt
+	return uptime, err
+}
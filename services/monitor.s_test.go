@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"waheim.api/models"
+)
+
+func TestMonitorProbe(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hello")) })
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/ok", http.StatusFound) })
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) })
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.Write([]byte("hello"))
+	})
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	s := &MonitorService{Client: srv.Client()}
+	cases := map[string]struct {
+		ok     bool
+		status int
+	}{
+		"/ok":    {true, 200},
+		"/moved": {true, 200},
+		"/down":  {false, 502},
+		"/slow":  {true, 200},
+	}
+	for path, want := range cases {
+		check := s.Probe(context.Background(), models.App{Id: "app", Uri: srv.URL + path})
+		if check.Ok != want.ok || check.StatusCode != want.status || check.AppId != "app" {
+			t.Errorf("%s: got %+v", path, check)
+		}
+		if !check.Ok && !check.Error.Valid {
+			t.Errorf("%s: failed probe has no error", path)
+		}
+	}
+
+	slow := s.Probe(context.Background(), models.App{Uri: srv.URL + "/slow"})
+	if slow.ResponseMs < 30 {
+		t.Errorf("slow probe ResponseMs = %d, want >= 30", slow.ResponseMs)
+	}
+
+	srv.Close()
+	check := s.Probe(context.Background(), models.App{Uri: srv.URL + "/ok"})
+	if check.Ok || check.StatusCode != 0 || !check.Error.Valid {
+		t.Fatalf("unreachable server: got %+v", check)
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+// NotificationList là một trang thông báo kèm tổng số chưa đọc
+type NotificationList struct {
+	Notifications []models.Notification `json:"notifications"`
+	UnreadCount   int                   `json:"unread_count"`
+}
+
+// NotificationService là hộp thư trong app: các phần khác gọi Notify, hoặc ghi domain event
+// mà notificationFromEvent biết cách chuyển thành thông báo
+type NotificationService struct {
+	Retention time.Duration
+}
+
+var notificationService = &NotificationService{
+	Retention: time.Duration(envInt("NOTIFICATION_RETENTION_DAYS", 90)) * 24 * time.Hour,
+}
+
+func NewNotificationService() *NotificationService {
+	return notificationService
+}
+
+// Notify gửi thông báo cho user, bỏ qua nếu user đã tắt loại này. data được lưu dạng JSON.
+func (s *NotificationService) Notify(ctx context.Context, userId, notificationType, title, body string, data interface{}) error {
+	n := models.Notification{UserId: userId, Type: notificationType, Title: title, Body: body}
+	return s.create(ctx, n, data)
+}
+
+func (s *NotificationService) create(ctx context.Context, n models.Notification, data interface{}) error {
+	if n.UserId == "" || !containsString(models.NotificationTypes, n.Type) {
+		return errors.New(configs.GetErrString(configs.ErrorCode_INVALID_NOTIFICATION_TYPE))
+	}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		n.Data = raw
+	}
+	_, err := repositories.CreateNotification(ctx, &n)
+	return err
+}
+
+// HandleEvent là EventHandler chuyển domain event thành thông báo; idempotent theo event.Id
+func (s *NotificationService) HandleEvent(ctx context.Context, event models.Event) error {
+	n, data, ok := notificationFromEvent(event)
+	if !ok || n.UserId == "" {
+		return nil
+	}
+	n.EventId = sql.NullString{String: event.Id, Valid: true}
+	return s.create(ctx, n, data)
+}
+
+// notificationFromEvent chọn người nhận và nội dung cho các event cần báo cho user
+func notificationFromEvent(event models.Event) (models.Notification, map[string]interface{}, bool) {
+	var n models.Notification
+	switch event.Type {
+	case models.EventBuildCompleted, models.EventBuildFailed:
+		var p models.BuildEvent
+		if json.Unmarshal(event.Payload, &p) != nil {
+			return n, nil, false
+		}
+		n = models.Notification{UserId: p.PublisherId, Type: models.NotificationBuild, Title: "Android build finished",
+			Body: "Your Android package is ready to install."}
+		if event.Type == models.EventBuildFailed {
+			n.Title, n.Body = "Android build failed", p.Reason
+		}
+		return n, map[string]interface{}{"app_id": p.AppId, "build_id": p.BuildId, "logs_url": p.LogsUrl}, true
+	case models.EventAppPublished:
+		// Store chưa có bước duyệt: publisher tự publish, nên đây chỉ là xác nhận app đã lên store,
+		// không phải thông báo được duyệt/từ chối
+		var p models.App
+		if json.Unmarshal(event.Payload, &p) != nil {
+			return n, nil, false
+		}
+		n = models.Notification{UserId: p.PublisherId, Type: models.NotificationAppStatus, Title: "App published",
+			Body: fmt.Sprintf("%s is now live on the store.", p.Name)}
+		return n, map[string]interface{}{"app_id": p.Id}, true
+	case models.EventAppUnhealthy, models.EventAppRecovered:
+		var p models.AppHealthEvent
+		if json.Unmarshal(event.Payload, &p) != nil {
+			return n, nil, false
+		}
+		n = models.Notification{UserId: p.PublisherId, Type: models.NotificationAppHealth, Title: "App is unreachable",
+			Body: fmt.Sprintf("%s failed %d health checks in a row.", p.Uri, p.Failures)}
+		if event.Type == models.EventAppRecovered {
+			n.Title, n.Body = "App is back online", fmt.Sprintf("%s is responding again.", p.Uri)
+		}
+		return n, map[string]interface{}{"app_id": p.AppId, "error": p.Error}, true
+	case models.EventRatingReplied:
+		var p models.RatingReplyEvent
+		if json.Unmarshal(event.Payload, &p) != nil {
+			return n, nil, false
+		}
+		n = models.Notification{UserId: p.ReviewerId, Type: models.NotificationReviewReply, Title: "The publisher replied to your review", Body: p.Reply}
+		if p.Edited {
+			n.Title = "The publisher updated their reply to your review"
+		}
+		return n, map[string]interface{}{"app_id": p.AppId, "rating_id": p.RatingId}, true
+	}
+	return n, nil, false
+}
+
+// List trả về một trang thông báo (limit 1..100, mặc định 20) kèm số thông báo chưa đọc
+func (s *NotificationService) List(ctx context.Context, userId string, unreadOnly bool, limit, offset int) (NotificationList, error) {
+	var list NotificationList
+	limit, offset = notificationPage(limit, offset)
+	var err error
+	if list.Notifications, err = repositories.GetNotifications(ctx, userId, unreadOnly, limit, offset); err != nil {
+		return list, err
+	}
+	list.UnreadCount, err = repositories.CountUnreadNotifications(ctx, userId)
+	return list, err
+}
+
+func notificationPage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userId, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return errors.New(configs.GetErrString(configs.ErrorCode_INVALID_REQUEST))
+	}
+	return repositories.MarkNotificationRead(ctx, userId, id)
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userId string) (int64, error) {
+	return repositories.MarkAllNotificationsRead(ctx, userId)
+}
+
+// Preferences trả về trạng thái bật/tắt của mọi loại thông báo (mặc định bật)
+func (s *NotificationService) Preferences(ctx context.Context, userId string) (map[string]bool, error) {
+	saved, err := repositories.GetNotificationPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	prefs := make(map[string]bool, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		enabled, ok := saved[t]
+		prefs[t] = enabled || !ok
+	}
+	return prefs, nil
+}
+
+// SetPreferences cập nhật các loại được truyền vào; không được tắt cảnh báo bảo mật
+func (s *NotificationService) SetPreferences(ctx context.Context, userId string, prefs map[string]bool) (map[string]bool, error) {
+	for t, enabled := range prefs {
+		if !containsString(models.NotificationTypes, t) || (t == models.NotificationSecurity && !enabled) {
+			return nil, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_NOTIFICATION_TYPE))
+		}
+	}
+	if err := repositories.SetNotificationPreferences(ctx, userId, prefs); err != nil {
+		return nil, err
+	}
+	return s.Preferences(ctx, userId)
+}
+
+func (s *NotificationService) Prune(ctx context.Context) error {
+	n, err := repositories.PruneNotifications(ctx, s.Retention)
+	if err == nil && n > 0 {
+		logger.InfoContext(ctx, "pruned notifications", "count", n)
+	}
+	return err
+}
+
+func init() {
+	for _, t := range []string{
+		models.EventBuildCompleted,
+		models.EventBuildFailed,
+		models.EventAppPublished,
+		models.EventAppUnhealthy,
+		models.EventAppRecovered,
+		models.EventRatingReplied,
+	} {
+		Events.Subscribe(t, notificationService.HandleEvent)
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+func TestNotificationFromEvent(t *testing.T) {
+	event := func(eventType string, payload interface{}) models.Event {
+		raw, _ := json.Marshal(payload)
+		return models.Event{Id: "e1", Type: eventType, Payload: raw}
+	}
+	cases := []struct {
+		event     models.Event
+		recipient string
+		kind      string
+	}{
+		{event(models.EventBuildFailed, models.BuildEvent{AppId: "a1", PublisherId: "p1", Reason: "timeout"}), "p1", models.NotificationBuild},
+		{event(models.EventAppPublished, models.App{Id: "a1", Name: "Notes", PublisherId: "p1"}), "p1", models.NotificationAppStatus},
+		{event(models.EventAppUnhealthy, models.AppHealthEvent{AppId: "a1", PublisherId: "p1", Failures: 3}), "p1", models.NotificationAppHealth},
+		{event(models.EventRatingReplied, models.RatingReplyEvent{RatingId: "r1", ReviewerId: "u1", PublisherId: "p1", Edited: true}), "u1", models.NotificationReviewReply},
+	}
+	for _, c := range cases {
+		n, data, ok := notificationFromEvent(c.event)
+		if !ok || n.UserId != c.recipient || n.Type != c.kind || n.Title == "" || data["app_id"] == nil {
+			t.Errorf("%s: unexpected notification %+v %v", c.event.Type, n, data)
+		}
+	}
+	if _, _, ok := notificationFromEvent(event(models.EventRatingPosted, map[string]string{"publisher_id": "p1"})); ok {
+		t.Error("rating.posted should not notify")
+	}
+}
+
+func TestNotificationPage(t *testing.T) {
+	cases := []struct{ limit, offset, wantLimit, wantOffset int }{
+		{0, 0, 20, 0},
+		{-5, -1, 20, 0},
+		{50, 10, 50, 10},
+		{1000, 0, 100, 0},
+	}
+	for _, c := range cases {
+		if limit, offset := notificationPage(c.limit, c.offset); limit != c.wantLimit || offset != c.wantOffset {
+			t.Errorf("notificationPage(%d, %d) = %d, %d", c.limit, c.offset, limit, offset)
+		}
+	}
+}
+
+func TestMarkReadRejectsMalformedId(t *testing.T) {
+	err := NewNotificationService().MarkRead(context.Background(), "u1", "not-a-uuid")
+	if err == nil || err.Error() != configs.GetErrString(configs.ErrorCode_INVALID_REQUEST) {
+		t.Fatalf("got %v, want INVALID_REQUEST", err)
+	}
+}
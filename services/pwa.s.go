@@ -0,0 +1,202 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+)
+
+const (
+	defaultThemeColor      = "#ffffff"
+	defaultBackgroundColor = "#ffffff"
+)
+
+var (
+	hexColorPattern  = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	iconSizesPattern = regexp.MustCompile(`^(any|\d+x\d+)( (any|\d+x\d+))*$`)
+	iconPurposes     = []string{"any", "maskable", "monochrome"}
+)
+
+// WebManifest là Web App Manifest sinh từ thông tin app
+type WebManifest struct {
+	Id              string           `json:"id"`
+	Name            string           `json:"name"`
+	ShortName       string           `json:"short_name"`
+	Description     string           `json:"description,omitempty"`
+	StartUrl        string           `json:"start_url"`
+	Scope           string           `json:"scope"`
+	Display         string           `json:"display"`
+	ThemeColor      string           `json:"theme_color"`
+	BackgroundColor string           `json:"background_color"`
+	Icons           []models.AppIcon `json:"icons"`
+	Screenshots     []models.AppIcon `json:"screenshots,omitempty"`
+	Categories      []string         `json:"categories,omitempty"`
+}
+
+type PwaService struct {
+	BaseUrl string
+}
+
+func NewPwaService() *PwaService {
+	return &PwaService{BaseUrl: os.Getenv("PUBLIC_BASE_URL")}
+}
+
+func (s *PwaService) ManifestUrl(appId string) string {
+	return s.BaseUrl + "/app/" + appId + "/manifest.webmanifest"
+}
+
+// Manifest sinh manifest cho app. App chỉ có một icon thì khai báo sizes "any".
+func (s *PwaService) Manifest(app models.App) WebManifest {
+	m := WebManifest{
+		Id:              app.Uri,
+		Name:            app.Name,
+		ShortName:       shortName(app.Name),
+		Description:     app.Description,
+		StartUrl:        app.Uri,
+		Scope:           appScope(app.Uri),
+		Display:         "standalone",
+		ThemeColor:      app.ThemeColor,
+		BackgroundColor: app.BackgroundColor,
+		Icons:           app.Icons,
+	}
+	if m.ThemeColor == "" {
+		m.ThemeColor = defaultThemeColor
+	}
+	if m.BackgroundColor == "" {
+		m.BackgroundColor = defaultBackgroundColor
+	}
+	if len(m.Icons) == 0 && app.Icon != "" {
+		m.Icons = []models.AppIcon{{Src: app.Icon, Sizes: "any", Purpose: "any"}}
+	}
+	if m.Icons == nil {
+		m.Icons = []models.AppIcon{}
+	}
+	for _, src := range app.ScreenShots {
+		m.Screenshots = append(m.Screenshots, models.AppIcon{Src: src})
+	}
+	if app.Category != "" {
+		m.Categories = []string{strings.ToLower(app.Category)}
+	}
+	return m
+}
+
+// short_name nên không quá 12 ký tự để hiển thị dưới icon
+func shortName(name string) string {
+	runes := []rune(strings.TrimSpace(name))
+	if len(runes) <= 12 {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:12]))
+}
+
+// appScope là thư mục chứa start_url
+func appScope(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return rawUrl
+	}
+	u.RawQuery, u.Fragment = "", ""
+	if i := strings.LastIndex(u.Path, "/"); i >= 0 {
+		u.Path = u.Path[:i+1]
+	} else {
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+var serviceWorkerTemplate = template.Must(template.New("sw").Parse(`// Starter service worker for {{.Name}}, generated by Waheim.
+// Serve this file from the root of your site and register it with:
+//   navigator.serviceWorker.register('/sw.js')
+const CACHE = {{.Cache}};
+const START_URL = {{.StartUrl}};
+
+self.addEventListener('install', (event) => {
+  event.waitUntil(caches.open(CACHE).then((cache) => cache.add(START_URL)));
+  self.skipWaiting();
+});
+
+self.addEventListener('activate', (event) => {
+  event.waitUntil(
+    caches.keys().then((keys) =>
+      Promise.all(keys.filter((key) => key !== CACHE).map((key) => caches.delete(key)))
+    )
+  );
+  self.clients.claim();
+});
+
+self.addEventListener('fetch', (event) => {
+  const request = event.request;
+  if (request.method !== 'GET' || new URL(request.url).origin !== self.location.origin) {
+    return;
+  }
+  if (request.mode === 'navigate') {
+    // Pages: network first, fall back to the cached copy when offline
+    event.respondWith(
+      fetch(request).catch(() => caches.match(request).then((hit) => hit || caches.match(START_URL)))
+    );
+    return;
+  }
+  // Static assets: cache first, cache whatever is fetched from the network
+  event.respondWith(
+    caches.match(request).then((hit) =>
+      hit ||
+      fetch(request).then((response) => {
+        if (response.ok) {
+          const copy = response.clone();
+          caches.open(CACHE).then((cache) => cache.put(request, copy));
+        }
+        return response;
+      })
+    )
+  );
+});
+`))
+
+// ServiceWorker sinh service worker khởi đầu (cache start_url, offline fallback)
+func (s *PwaService) ServiceWorker(app models.App) ([]byte, error) {
+	// Giá trị được encode JSON nên luôn là literal JS hợp lệ
+	literal := func(v string) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	var buf bytes.Buffer
+	err := serviceWorkerTemplate.Execute(&buf, map[string]string{
+		"Name":     strings.NewReplacer("\n", " ", "\r", " ").Replace(app.Name),
+		"Cache":    literal("waheim-" + app.Id + "-" + app.UpdatedAt),
+		"StartUrl": literal(app.Uri),
+	})
+	return buf.Bytes(), err
+}
+
+// validateAppTheme kiểm tra màu (hex CSS) và danh sách icon trước khi lưu
+func validateAppTheme(themeColor, backgroundColor string, icons models.AppIcons) error {
+	for _, c := range []string{themeColor, backgroundColor} {
+		if c != "" && !hexColorPattern.MatchString(c) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_INVALID_THEME_COLOR))
+		}
+	}
+	invalidIcon := errors.New(configs.GetErrString(configs.ErrorCode_INVALID_APP_ICON))
+	for _, icon := range icons {
+		u, err := url.Parse(icon.Src)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return invalidIcon
+		}
+		if icon.Sizes != "" && !iconSizesPattern.MatchString(icon.Sizes) {
+			return invalidIcon
+		}
+		for _, p := range strings.Fields(icon.Purpose) {
+			if !containsString(iconPurposes, p) {
+				return invalidIcon
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"waheim.api/repositories"
+)
+
+// RankingService định kỳ tính rating_score (trung bình Bayes) và trending_score
+// (hoạt động gần đây, giảm dần theo thời gian) cho sort=top và sort=trending
+type RankingService struct {
+	PriorVotes float64
+	HalfLife   time.Duration
+	Window     time.Duration
+}
+
+// NewRankingService đọc RANKING_PRIOR_VOTES (mặc định 10) và
+// RANKING_TRENDING_HALF_LIFE_HOURS (mặc định 48); trending chỉ xét 14 ngày gần nhất
+func NewRankingService() *RankingService {
+	return &RankingService{
+		PriorVotes: float64(envInt("RANKING_PRIOR_VOTES", 10)),
+		HalfLife:   time.Duration(envInt("RANKING_TRENDING_HALF_LIFE_HOURS", 48)) * time.Hour,
+		Window:     14 * 24 * time.Hour,
+	}
+}
+
+func (s *RankingService) Refresh(ctx context.Context) error {
+	n, err := repositories.RefreshAppRankings(ctx, s.PriorVotes, s.HalfLife, s.Window)
+	if err == nil {
+		logger.DebugContext(ctx, "refreshed app rankings", "apps", n)
+	}
+	return err
+}
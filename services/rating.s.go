@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+const (
+	maxReportDetails = 500
+	maxReplyLength   = 2000
+)
+
+// RatingService: Filters chạy trên comment khi gửi đánh giá; đánh giá nhận đủ ReportThreshold
+// báo cáo chưa xử lý thì bị ẩn chờ kiểm duyệt
+type RatingService struct {
+	Filters         []ContentFilter
+	ReportThreshold int
+}
+
+func NewRatingService() *RatingService {
+	return &RatingService{
+		Filters:         defaultContentFilters(),
+		ReportThreshold: max(envInt("RATING_REPORT_THRESHOLD", 3), 1),
+	}
+}
+
+func (s *RatingService) PostRating(ctx context.Context, userId, appId string, stars int, comment string) (models.Rating, error) {
+	if stars < 1 || stars > 5 {
+		return models.Rating{}, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_RATING_STARS))
+	}
+	comment = strings.TrimSpace(comment)
+	status := models.RatingStatusPublished
+	if comment != "" {
+		switch checkContent(ctx, s.Filters, comment) {
+		case ContentReject:
+			return models.Rating{}, errors.New(configs.GetErrString(configs.ErrorCode_RATING_COMMENT_REJECTED))
+		case ContentHold:
+			status = models.RatingStatusPending
+		}
+	}
+	rating := models.Rating{
+		UserId:  userId,
+		AppId:   appId,
+		Stars:   stars,
+		Comment: sql.NullString{String: comment, Valid: comment != ""},
+		Status:  sql.NullString{String: status, Valid: true},
+	}
+	err := repositories.CreateRating(ctx, &rating)
+	return rating, err
+}
+
+func (s *RatingService) GetAppRatings(ctx context.Context, appId string, limit, offset int) ([]models.Rating, error) {
+	return repositories.GetRatingsByApp(ctx, appId, limit, offset)
+}
+
+// SetHelpful đánh dấu hoặc bỏ đánh dấu hữu ích, trả về đánh giá với helpful_count mới
+func (s *RatingService) SetHelpful(ctx context.Context, userId, appId, ratingId string, helpful bool) (models.Rating, error) {
+	if helpful {
+		return repositories.AddHelpfulVote(ctx, userId, appId, ratingId)
+	}
+	return repositories.RemoveHelpfulVote(ctx, userId, appId, ratingId)
+}
+
+// Report ghi báo cáo vi phạm của user cho đánh giá
+func (s *RatingService) Report(ctx context.Context, userId, appId, ratingId, reason, details string) (models.RatingReport, error) {
+	details = strings.TrimSpace(details)
+	if !containsString(models.RatingReportReasons, reason) || utf8.RuneCountInString(details) > maxReportDetails {
+		return models.RatingReport{}, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_REPORT_REASON))
+	}
+	report := models.RatingReport{
+		RatingId: ratingId,
+		UserId:   sql.NullString{String: userId, Valid: true},
+		Reason:   reason,
+		Details:  sql.NullString{String: details, Valid: details != ""},
+	}
+	hidden, err := repositories.ReportRating(ctx, appId, &report, s.ReportThreshold)
+	if hidden {
+		logger.InfoContext(ctx, "rating hidden pending moderation", "rating_id", ratingId, "threshold", s.ReportThreshold)
+	}
+	return report, err
+}
+
+// ModerationQueue trả về đánh giá cần admin xem xét, mặc định là đánh giá đang chờ kiểm duyệt
+func (s *RatingService) ModerationQueue(ctx context.Context, status string, limit, offset int) ([]models.RatingModeration, error) {
+	if status == "" {
+		status = models.RatingStatusPending
+	}
+	if !validRatingStatus(status) {
+		return nil, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_RATING_STATUS))
+	}
+	return repositories.GetRatingModerationQueue(ctx, status, limit, offset)
+}
+
+// Moderate là quyết định của admin: published để hiển thị lại, hidden để ẩn hẳn
+func (s *RatingService) Moderate(ctx context.Context, ratingId, status string) (models.Rating, error) {
+	if status != models.RatingStatusPublished && status != models.RatingStatusHidden {
+		return models.Rating{}, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_RATING_STATUS))
+	}
+	return repositories.ModerateRating(ctx, ratingId, status)
+}
+
+func validRatingStatus(status string) bool {
+	return status == models.RatingStatusPublished || status == models.RatingStatusPending || status == models.RatingStatusHidden
+}
+
+// Reply tạo hoặc sửa phản hồi của publisher cho đánh giá về app của họ
+func (s *RatingService) Reply(ctx context.Context, publisherId, appId, ratingId, body string) (models.RatingReply, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxReplyLength {
+		return models.RatingReply{}, errors.New(configs.GetErrString(configs.ErrorCode_INVALID_RATING_REPLY))
+	}
+	return repositories.SaveRatingReply(ctx, publisherId, appId, ratingId, body)
+}
+
+func (s *RatingService) DeleteReply(ctx context.Context, publisherId, appId, ratingId string) error {
+	return repositories.DeleteRatingReply(ctx, publisherId, appId, ratingId)
+}
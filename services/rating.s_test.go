@@ -0,0 +1,19 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"waheim.api/configs"
+)
+
+func TestRatingReplyValidation(t *testing.T) {
+	s := &RatingService{}
+	for _, body := range []string{"", "   ", strings.Repeat("ă", maxReplyLength+1)} {
+		_, err := s.Reply(context.Background(), "publisher", "app", "rating", body)
+		if err == nil || err.Error() != configs.GetErrString(configs.ErrorCode_INVALID_RATING_REPLY) {
+			t.Errorf("%d chars: got %v", len(body), err)
+		}
+	}
+}
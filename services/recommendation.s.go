@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+// RecommendationService trả về app tương tự và gợi ý cá nhân hoá. Gợi ý được job
+// nền tính sẵn vào user_recommendations, chỉ dùng dữ liệu cài, đánh giá, category và tag.
+type RecommendationService struct {
+	TTL       time.Duration
+	Size      int
+	BatchSize int
+}
+
+// NewRecommendationService đọc RECOMMENDATION_TTL_HOURS (mặc định 24)
+func NewRecommendationService() *RecommendationService {
+	return &RecommendationService{
+		TTL:       time.Duration(envInt("RECOMMENDATION_TTL_HOURS", 24)) * time.Hour,
+		Size:      50,
+		BatchSize: 100,
+	}
+}
+
+func (s *RecommendationService) Similar(ctx context.Context, appId string, limit int) ([]models.App, error) {
+	if _, err := repositories.GetAppById(ctx, appId); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	return repositories.GetSimilarApps(ctx, appId, limit)
+}
+
+// ForUser trả về gợi ý đã tính sẵn; user chưa được tính thì tính ngay một lần,
+// user chưa có lịch sử thì dùng app đánh giá cao
+func (s *RecommendationService) ForUser(ctx context.Context, userId string, limit, offset int) ([]models.App, error) {
+	rec, found, err := repositories.GetUserRecommendations(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if rec, err = repositories.RefreshUserRecommendations(ctx, userId, s.Size); err != nil {
+			return nil, err
+		}
+	}
+	if limit <= 0 || limit > s.Size {
+		limit = s.Size
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if len(rec.AppIds) == 0 {
+		apps, err := repositories.GetTopRatedApps(ctx, offset+limit)
+		if err != nil || offset >= len(apps) {
+			return []models.App{}, err
+		}
+		return apps[offset:], nil
+	}
+	ids := rec.AppIds
+	if offset >= len(ids) {
+		return []models.App{}, nil
+	}
+	ids = ids[offset:]
+	if limit < len(ids) {
+		ids = ids[:limit]
+	}
+	return repositories.GetPublishedAppsByIds(ctx, ids)
+}
+
+// RefreshDue tính lại gợi ý cho các user tới hạn, mỗi lần tối đa BatchSize user
+func (s *RecommendationService) RefreshDue(ctx context.Context) error {
+	userIds, err := repositories.GetUsersDueForRecommendations(ctx, s.TTL, s.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, userId := range userIds {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := repositories.RefreshUserRecommendations(ctx, userId, s.Size); err != nil {
+			return err
+		}
+	}
+	if len(userIds) > 0 {
+		logger.InfoContext(ctx, "refreshed recommendations", "users", len(userIds))
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"waheim.api/configs"
+)
+
+var logger = configs.Logger("services")
+
+// RunEvery chạy job ngay lập tức rồi lặp lại theo interval cho tới khi ctx bị huỷ
+func RunEvery(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := job(ctx); err != nil {
+			logger.ErrorContext(ctx, "job failed", "job", name, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
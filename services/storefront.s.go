@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+const maxCollectionApps = 100
+
+// Collection tự sinh luôn đứng sau collection curated, slug của chúng không dùng được cho collection curated
+var autoCollections = []models.Collection{
+	{Slug: "top-rated", Titles: models.LocalizedText{"en": "Top rated", "vi": "Đánh giá cao"}},
+	{Slug: "trending", Titles: models.LocalizedText{"en": "Trending", "vi": "Thịnh hành"}},
+	{Slug: "new-releases", Titles: models.LocalizedText{"en": "New releases", "vi": "Mới phát hành"}},
+}
+
+// Storefront là toàn bộ dữ liệu trang chủ store
+type Storefront struct {
+	Collections []models.Collection `json:"collections"`
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// CollectionInput là body tạo/sửa collection; sửa là thay toàn bộ các field
+type CollectionInput struct {
+	Slug      string               `json:"slug"`
+	Titles    models.LocalizedText `json:"titles"`
+	BannerUrl string               `json:"banner_url,omitempty"`
+	Position  int                  `json:"position"`
+	StartsAt  *time.Time           `json:"starts_at,omitempty"`
+	EndsAt    *time.Time           `json:"ends_at,omitempty"`
+}
+
+// StorefrontService dựng trang chủ store và giữ kết quả trong bộ nhớ TTL giây
+type StorefrontService struct {
+	Size int
+	TTL  time.Duration
+
+	mu      sync.Mutex
+	cached  *Storefront
+	expires time.Time
+}
+
+var storefrontService = &StorefrontService{
+	Size: envInt("STOREFRONT_COLLECTION_SIZE", 12),
+	TTL:  time.Duration(envInt("STOREFRONT_CACHE_SECONDS", 60)) * time.Second,
+}
+
+// NewStorefrontService trả về instance dùng chung để admin sửa collection thì cache bị xoá ngay
+func NewStorefrontService() *StorefrontService {
+	return storefrontService
+}
+
+// Storefront trả về collection curated đang hiển thị rồi tới các collection tự sinh, title theo locale
+func (s *StorefrontService) Storefront(ctx context.Context, locale string) (Storefront, error) {
+	s.mu.Lock()
+	cached, expires := s.cached, s.expires
+	s.mu.Unlock()
+	if cached == nil || time.Now().After(expires) {
+		built, err := s.build(ctx)
+		if err != nil {
+			return Storefront{}, err
+		}
+		s.mu.Lock()
+		s.cached, s.expires = &built, time.Now().Add(s.TTL)
+		s.mu.Unlock()
+		cached = &built
+	}
+	// Bản sao để gán Title theo locale mà không sửa cache
+	front := Storefront{GeneratedAt: cached.GeneratedAt, Collections: make([]models.Collection, len(cached.Collections))}
+	for i, c := range cached.Collections {
+		c.Title = c.Titles.Get(locale)
+		front.Collections[i] = c
+	}
+	return front, nil
+}
+
+func (s *StorefrontService) build(ctx context.Context) (Storefront, error) {
+	front := Storefront{GeneratedAt: time.Now().UTC()}
+	collections, err := repositories.GetCollections(ctx, true)
+	if err != nil {
+		return front, err
+	}
+	ids := make([]string, len(collections))
+	for i, c := range collections {
+		ids[i] = c.Id
+	}
+	apps, err := repositories.GetCollectionApps(ctx, ids, s.Size, true)
+	if err != nil {
+		return front, err
+	}
+	for _, c := range collections {
+		c.Kind = models.CollectionKindCurated
+		c.Apps = apps[c.Id]
+		// Collection chưa có app published thì không hiển thị
+		if len(c.Apps) > 0 {
+			front.Collections = append(front.Collections, c)
+		}
+	}
+	loaders := map[string]func() ([]models.App, error){
+		"top-rated":    func() ([]models.App, error) { return repositories.GetTopRatedApps(ctx, s.Size) },
+		"trending":     func() ([]models.App, error) { return repositories.GetTrendingApps(ctx, s.Size) },
+		"new-releases": func() ([]models.App, error) { return repositories.GetNewApps(ctx, s.Size) },
+	}
+	for _, c := range autoCollections {
+		c.Kind = models.CollectionKindAuto
+		if c.Apps, err = loaders[c.Slug](); err != nil {
+			return front, err
+		}
+		if len(c.Apps) > 0 {
+			front.Collections = append(front.Collections, c)
+		}
+	}
+	return front, nil
+}
+
+// Invalidate xoá cache để thay đổi của admin hiển thị ngay
+func (s *StorefrontService) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cached = nil
+}
+
+// GetCollections trả về mọi collection curated (kể cả ngoài lịch) kèm toàn bộ app
+func (s *StorefrontService) GetCollections(ctx context.Context, locale string) ([]models.Collection, error) {
+	collections, err := repositories.GetCollections(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(collections))
+	for i, c := range collections {
+		ids[i] = c.Id
+	}
+	apps, err := repositories.GetCollectionApps(ctx, ids, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range collections {
+		collections[i].Kind = models.CollectionKindCurated
+		collections[i].Title = collections[i].Titles.Get(locale)
+		collections[i].Apps = apps[collections[i].Id]
+		if collections[i].Apps == nil {
+			collections[i].Apps = []models.App{}
+		}
+	}
+	return collections, nil
+}
+
+func (s *StorefrontService) CreateCollection(ctx context.Context, input CollectionInput) (models.Collection, error) {
+	collection := models.Collection{Kind: models.CollectionKindCurated, Apps: []models.App{}}
+	if err := applyCollectionInput(&collection, input); err != nil {
+		return collection, err
+	}
+	if err := repositories.CreateCollection(ctx, &collection); err != nil {
+		return collection, err
+	}
+	s.Invalidate()
+	collection.Title = collection.Titles.Get("")
+	return collection, nil
+}
+
+func (s *StorefrontService) UpdateCollection(ctx context.Context, id string, input CollectionInput) (models.Collection, error) {
+	collection := models.Collection{Id: id, Kind: models.CollectionKindCurated}
+	if err := applyCollectionInput(&collection, input); err != nil {
+		return collection, err
+	}
+	if err := repositories.UpdateCollection(ctx, &collection); err != nil {
+		return collection, err
+	}
+	s.Invalidate()
+	collection.Title = collection.Titles.Get("")
+	apps, err := repositories.GetCollectionApps(ctx, []string{id}, 0, false)
+	collection.Apps = apps[id]
+	return collection, err
+}
+
+func (s *StorefrontService) DeleteCollection(ctx context.Context, id string) error {
+	if err := repositories.DeleteCollection(ctx, id); err != nil {
+		return err
+	}
+	s.Invalidate()
+	return nil
+}
+
+// SetCollectionApps thay danh sách app của collection theo thứ tự truyền vào (bỏ id trùng)
+func (s *StorefrontService) SetCollectionApps(ctx context.Context, id string, appIds []string) error {
+	unique := []string{}
+	for _, appId := range appIds {
+		if !containsString(unique, appId) {
+			unique = append(unique, appId)
+		}
+	}
+	if len(unique) > maxCollectionApps {
+		return errors.New(configs.GetErrString(configs.ErrorCode_INVALID_COLLECTION))
+	}
+	if err := repositories.SetCollectionApps(ctx, id, unique); err != nil {
+		return err
+	}
+	s.Invalidate()
+	return nil
+}
+
+func applyCollectionInput(collection *models.Collection, input CollectionInput) error {
+	invalid := errors.New(configs.GetErrString(configs.ErrorCode_INVALID_COLLECTION))
+	slug, ok := NormalizeSlug(input.Slug)
+	if !ok {
+		return invalid
+	}
+	for _, auto := range autoCollections {
+		if auto.Slug == slug {
+			return errors.New(configs.GetErrString(configs.ErrorCode_SLUG_ALREADY_EXISTS))
+		}
+	}
+	titles := models.LocalizedText{}
+	for locale, title := range input.Titles {
+		if locale != "" && title != "" {
+			titles[locale] = title
+		}
+	}
+	if len(titles) == 0 {
+		return invalid
+	}
+	if input.BannerUrl != "" {
+		u, err := url.Parse(input.BannerUrl)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return invalid
+		}
+	}
+	if input.StartsAt != nil && input.EndsAt != nil && !input.EndsAt.After(*input.StartsAt) {
+		return invalid
+	}
+	collection.Slug = slug
+	collection.Titles = titles
+	collection.BannerUrl = input.BannerUrl
+	collection.Position = input.Position
+	collection.StartsAt = nullTime(input.StartsAt)
+	collection.EndsAt = nullTime(input.EndsAt)
+	return nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
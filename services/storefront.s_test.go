@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"waheim.api/models"
+)
+
+func TestApplyCollectionInput(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(7 * 24 * time.Hour)
+	var c models.Collection
+	err := applyCollectionInput(&c, CollectionInput{
+		Slug:     "Editor's Picks",
+		Titles:   models.LocalizedText{"en": "Editor's picks"},
+		StartsAt: &start,
+		EndsAt:   &end,
+	})
+	if err == nil {
+		t.Fatal("slug with apostrophe should be rejected")
+	}
+	err = applyCollectionInput(&c, CollectionInput{
+		Slug:     "Editors Picks",
+		Titles:   models.LocalizedText{"en": "Editor's picks", "vi": ""},
+		StartsAt: &start,
+		EndsAt:   &end,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Slug != "editors-picks" || len(c.Titles) != 1 || !c.StartsAt.Valid || !c.EndsAt.Time.Equal(end) {
+		t.Fatalf("unexpected collection %+v", c)
+	}
+
+	invalid := []CollectionInput{
+		{Slug: "trending", Titles: models.LocalizedText{"en": "Trending"}},
+		{Slug: "picks", Titles: models.LocalizedText{}},
+		{Slug: "picks", Titles: models.LocalizedText{"en": "Picks"}, BannerUrl: "javascript:alert(1)"},
+		{Slug: "picks", Titles: models.LocalizedText{"en": "Picks"}, StartsAt: &end, EndsAt: &start},
+	}
+	for i, input := range invalid {
+		if err := applyCollectionInput(&c, input); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
@@ -1,47 +1,49 @@
 package services
 
 import (
+	"context"
 	"waheim.api/models"
 	"waheim.api/repositories"
 )
 
 type UserService interface {
-	SignUp(request map[string]string) error
-	SignIn(request map[string]string) (string, error)
-	AuthMe(token string) (models.User, error)
-	GetAllUsers(filters map[string]string, limit, offset int) ([]models.User, error)
-	GetUserById(id string) (models.User, error)
-	UpdateUser(id string, updates map[string]interface{}) error
-	DeleteUser(id string) error
+	SignUp(ctx context.Context, request map[string]string) error
+	SignIn(ctx context.Context, request map[string]string, ip, userAgent string) (string, error)
+	AuthMe(ctx context.Context, token string) (models.User, error)
+	GetAllUsers(ctx context.Context, filters map[string]string, limit, offset int) ([]models.User, error)
+	GetUserById(ctx context.Context, id string) (models.User, error)
+	UpdateUser(ctx context.Context, id string, updates map[string]interface{}) error
+	DeleteUser(ctx context.Context, id string) error
 }
-func (u *userServiceImpl) GetUserById(id string) (models.User, error) {
-	return repositories.GetUserById(id)
+
+func (u *userServiceImpl) GetUserById(ctx context.Context, id string) (models.User, error) {
+	return repositories.GetUserById(ctx, id)
 }
 
-func (u *userServiceImpl) UpdateUser(id string, updates map[string]interface{}) error {
-	return repositories.UpdateUser(id, updates)
+func (u *userServiceImpl) UpdateUser(ctx context.Context, id string, updates map[string]interface{}) error {
+	return repositories.UpdateUser(ctx, id, updates)
 }
 
-func (u *userServiceImpl) DeleteUser(id string) error {
-	return repositories.DeleteUser(id)
+func (u *userServiceImpl) DeleteUser(ctx context.Context, id string) error {
+	return repositories.DeleteUser(ctx, id)
 }
 
 type userServiceImpl struct{}
 
-func (u *userServiceImpl) SignUp(request map[string]string) error {
-	return repositories.SignUp(request)
+func (u *userServiceImpl) SignUp(ctx context.Context, request map[string]string) error {
+	return repositories.SignUp(ctx, request)
 }
 
-func (u *userServiceImpl) SignIn(request map[string]string) (string, error) {
-	return repositories.SignIn(request)
+func (u *userServiceImpl) SignIn(ctx context.Context, request map[string]string, ip, userAgent string) (string, error) {
+	return repositories.SignIn(ctx, request, ip, userAgent)
 }
 
-func (u *userServiceImpl) AuthMe(token string) (models.User, error) {
-	return repositories.AuthMe(token)
+func (u *userServiceImpl) AuthMe(ctx context.Context, token string) (models.User, error) {
+	return repositories.AuthMe(ctx, token)
 }
 
-func (u *userServiceImpl) GetAllUsers(filters map[string]string, limit, offset int) ([]models.User, error) {
-	return repositories.GetAllUsers(filters, limit, offset)
+func (u *userServiceImpl) GetAllUsers(ctx context.Context, filters map[string]string, limit, offset int) ([]models.User, error) {
+	return repositories.GetAllUsers(ctx, filters, limit, offset)
 }
 
 func NewUserService() UserService {
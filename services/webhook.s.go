@@ -0,0 +1,348 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+	"waheim.api/configs"
+	"waheim.api/models"
+	"waheim.api/repositories"
+)
+
+// Các loại event publisher có thể đăng ký nhận qua webhook
+var WebhookEventTypes = []string{
+	models.EventAppCreated,
+	models.EventAppPublished,
+	models.EventAppDeleted,
+	models.EventBuildCompleted,
+	models.EventBuildFailed,
+	models.EventRatingPosted,
+	models.EventAppUnhealthy,
+	models.EventAppRecovered,
+}
+
+const webhookResponseLimit = 2048
+
+// webhookLease là thời gian một delivery được giữ cho người đang gửi trước khi worker được nhận lại
+const webhookLease = 2 * time.Minute
+
+// WebhookEnvelope là body JSON gửi tới URL của publisher
+type WebhookEnvelope struct {
+	Id        string         `json:"id"`
+	Type      string         `json:"type"`
+	CreatedAt time.Time      `json:"created_at"`
+	Data      types.JSONText `json:"data"`
+}
+
+// WebhookAttempt là kết quả của một lần gửi
+type WebhookAttempt struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+	Err        error
+}
+
+func (a WebhookAttempt) Ok() bool {
+	return a.Err == nil && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// SignWebhookPayload trả về "sha256=<hex>" với HMAC-SHA256 của "<timestamp>.<body>".
+// Receiver tính lại từ header X-Waheim-Timestamp và body thô để xác thực.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookSender gửi một delivery tới subscription, không đụng tới DB
+type WebhookSender struct {
+	Client *http.Client
+	Now    func() time.Time
+}
+
+func (s *WebhookSender) Send(ctx context.Context, sub models.WebhookSubscription, delivery models.WebhookDelivery) WebhookAttempt {
+	body, err := json.Marshal(WebhookEnvelope{
+		Id:        delivery.EventId,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return WebhookAttempt{Err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", sub.Url, bytes.NewReader(body))
+	if err != nil {
+		return WebhookAttempt{Err: err}
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Waheim-Webhooks/1.0")
+	req.Header.Set("X-Waheim-Event", delivery.EventType)
+	req.Header.Set("X-Waheim-Delivery", delivery.Id)
+	req.Header.Set("X-Waheim-Timestamp", timestamp)
+	req.Header.Set("X-Waheim-Signature", SignWebhookPayload(sub.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return WebhookAttempt{Duration: time.Since(start), Err: err}
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	return WebhookAttempt{StatusCode: resp.StatusCode, Body: string(respBody), Duration: time.Since(start)}
+}
+
+type WebhookService struct {
+	Sender      *WebhookSender
+	MaxAttempts int
+	BatchSize   int
+}
+
+// NewWebhookService đọc WEBHOOK_MAX_ATTEMPTS (mặc định 8)
+func NewWebhookService() *WebhookService {
+	return &WebhookService{
+		Sender:      &WebhookSender{Client: configs.SafeClient},
+		MaxAttempts: envInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		BatchSize:   20,
+	}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, publisherId, rawUrl string, eventTypes []string) (models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := validateWebhookUrl(rawUrl); err != nil {
+		return sub, err
+	}
+	if err := validateWebhookEvents(eventTypes); err != nil {
+		return sub, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return sub, errors.New(configs.GetErrString(configs.ErrorCode_FAILED_TO_GENERATE_KEY))
+	}
+	sub.PublisherId = publisherId
+	sub.Url = rawUrl
+	sub.Secret = "whsec_" + base64.RawURLEncoding.EncodeToString(secret)
+	sub.EventTypes = pq.StringArray(eventTypes)
+	if sub.EventTypes == nil {
+		sub.EventTypes = pq.StringArray{}
+	}
+	sub.Active = true
+	if err := repositories.CreateWebhookSubscription(ctx, &sub); err != nil {
+		return sub, err
+	}
+	// Secret chỉ trả về một lần khi tạo
+	return sub, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, publisherId string) ([]models.WebhookSubscription, error) {
+	subs, err := repositories.GetWebhookSubscriptions(ctx, publisherId)
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, err
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id, publisherId string, rawUrl *string, eventTypes []string, active *bool) (models.WebhookSubscription, error) {
+	sub, err := repositories.GetWebhookSubscription(ctx, id, publisherId)
+	if err != nil {
+		return sub, err
+	}
+	if rawUrl != nil {
+		if err := validateWebhookUrl(*rawUrl); err != nil {
+			return sub, err
+		}
+		sub.Url = *rawUrl
+	}
+	if eventTypes != nil {
+		if err := validateWebhookEvents(eventTypes); err != nil {
+			return sub, err
+		}
+		sub.EventTypes = pq.StringArray(eventTypes)
+	}
+	if active != nil {
+		sub.Active = *active
+	}
+	if err := repositories.UpdateWebhookSubscription(ctx, &sub); err != nil {
+		return sub, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id, publisherId string) error {
+	return repositories.DeleteWebhookSubscription(ctx, id, publisherId)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, id, publisherId string, limit, offset int) ([]models.WebhookDelivery, error) {
+	if _, err := repositories.GetWebhookSubscription(ctx, id, publisherId); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return repositories.GetWebhookDeliveries(ctx, id, limit, offset)
+}
+
+// SendTest gửi ngay một event webhook.test (không retry) và trả về delivery đã ghi log
+func (s *WebhookService) SendTest(ctx context.Context, id, publisherId string) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	sub, err := repositories.GetWebhookSubscription(ctx, id, publisherId)
+	if err != nil {
+		return delivery, err
+	}
+	eventId := make([]byte, 16)
+	if _, err := rand.Read(eventId); err != nil {
+		return delivery, errors.New(configs.GetErrString(configs.ErrorCode_FAILED_TO_GENERATE_KEY))
+	}
+	payload, _ := json.Marshal(map[string]string{"webhook_id": sub.Id, "message": "This is a test event from Waheim"})
+	delivery.SubscriptionId = sub.Id
+	delivery.EventId = "test_" + hex.EncodeToString(eventId)
+	delivery.EventType = models.EventWebhookTest
+	delivery.Payload = types.JSONText(payload)
+	if err := repositories.CreateWebhookDelivery(ctx, &delivery, webhookLease); err != nil {
+		return delivery, err
+	}
+	attempt := s.Sender.Send(ctx, sub, delivery)
+	s.applyAttempt(&delivery, attempt, 1)
+	if err := repositories.SaveWebhookAttempt(ctx, &delivery); err != nil {
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+// Enqueue tạo delivery cho các subscription của publisher sở hữu event.
+// Được gọi từ EventBus nên event đã được commit.
+func (s *WebhookService) Enqueue(ctx context.Context, event models.Event) error {
+	if !containsString(WebhookEventTypes, event.Type) {
+		return nil
+	}
+	var owner struct {
+		PublisherId string `json:"publisher_id"`
+	}
+	if err := json.Unmarshal(event.Payload, &owner); err != nil || owner.PublisherId == "" {
+		return nil
+	}
+	return repositories.EnqueueWebhookDeliveries(ctx, owner.PublisherId, event)
+}
+
+// ProcessPending gửi các delivery đến hạn, retry với backoff luỹ thừa
+func (s *WebhookService) ProcessPending(ctx context.Context) error {
+	for ctx.Err() == nil {
+		jobs, err := repositories.ClaimWebhookDeliveries(ctx, s.BatchSize, webhookLease)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		for _, job := range jobs {
+			s.deliver(ctx, job)
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *WebhookService) deliver(ctx context.Context, job repositories.WebhookJob) {
+	delivery := job.Delivery
+	sub := job.Subscription
+	var attempt WebhookAttempt
+	if sub.Id == "" || !sub.Active || sub.DeletedAt.Valid {
+		attempt = WebhookAttempt{Err: errors.New("subscription disabled")}
+		delivery.Attempts = s.MaxAttempts - 1
+	} else {
+		attempt = s.Sender.Send(ctx, sub, delivery)
+	}
+	s.applyAttempt(&delivery, attempt, s.MaxAttempts)
+	if delivery.Status != models.WebhookDeliveryDelivered {
+		logger.WarnContext(ctx, "webhook delivery failed", "delivery_id", delivery.Id, "subscription_id", delivery.SubscriptionId,
+			"attempt", delivery.Attempts, "status", delivery.Status, "response_code", attempt.StatusCode, "error", attempt.Err)
+	}
+	repositories.SaveWebhookAttempt(ctx, &delivery)
+}
+
+// applyAttempt cập nhật delivery theo kết quả gửi; hết maxAttempts thì chuyển failed
+func (s *WebhookService) applyAttempt(delivery *models.WebhookDelivery, attempt WebhookAttempt, maxAttempts int) {
+	delivery.Attempts++
+	delivery.DurationMs = sql.NullInt64{Int64: attempt.Duration.Milliseconds(), Valid: true}
+	delivery.ResponseCode = sql.NullInt64{Int64: int64(attempt.StatusCode), Valid: attempt.StatusCode != 0}
+	delivery.ResponseBody = sql.NullString{String: attempt.Body, Valid: attempt.Body != ""}
+	delivery.Error = sql.NullString{}
+	if attempt.Ok() {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = sql.NullTime{Time: time.Now(), Valid: true}
+		return
+	}
+	if attempt.Err != nil {
+		delivery.Error = sql.NullString{String: attempt.Err.Error(), Valid: true}
+	} else {
+		delivery.Error = sql.NullString{String: "receiver responded " + strconv.Itoa(attempt.StatusCode), Valid: true}
+	}
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		return
+	}
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.NextAttemptAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+}
+
+// webhookBackoff: 30s, 1m, 2m, 4m... tối đa 6 giờ
+func webhookBackoff(attempts int) time.Duration {
+	d := 30 * time.Second * time.Duration(math.Pow(2, float64(attempts-1)))
+	if d > 6*time.Hour || d <= 0 {
+		return 6 * time.Hour
+	}
+	return d
+}
+
+// validateWebhookUrl yêu cầu https, trừ khi WEBHOOK_ALLOW_HTTP=true (dùng khi phát triển)
+func validateWebhookUrl(rawUrl string) error {
+	invalid := errors.New(configs.GetErrString(configs.ErrorCode_INVALID_WEBHOOK_URL))
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return invalid
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && os.Getenv("WEBHOOK_ALLOW_HTTP") == "true") {
+		return invalid
+	}
+	return nil
+}
+
+func validateWebhookEvents(eventTypes []string) error {
+	for _, t := range eventTypes {
+		if !containsString(WebhookEventTypes, t) {
+			return errors.New(configs.GetErrString(configs.ErrorCode_INVALID_WEBHOOK_EVENT))
+		}
+	}
+	return nil
+}
+
+func init() {
+	webhooks := NewWebhookService()
+	Events.Subscribe("*", webhooks.Enqueue)
+	// Chỉ tính lần gửi đầu: receiver của publisher bị lỗi không được làm API mất ready
+	Health.AddReadiness("webhook_queue", QueueCheck("webhook_deliveries", "status = 'pending' AND attempts = 0"))
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"waheim.api/models"
+)
+
+func TestWebhookSenderSignsDelivery(t *testing.T) {
+	var got struct {
+		header http.Header
+		body   []byte
+	}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.header = r.Header.Clone()
+		got.body, _ = io.ReadAll(r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	sender := &WebhookSender{Client: receiver.Client(), Now: func() time.Time { return time.Unix(1700000000, 0) }}
+	sub := models.WebhookSubscription{Id: "sub-1", Url: receiver.URL, Secret: "whsec_test"}
+	delivery := models.WebhookDelivery{
+		Id:        "del-1",
+		EventId:   "evt-1",
+		EventType: models.EventBuildCompleted,
+		Payload:   []byte(`{"app_id":"app-1"}`),
+	}
+
+	attempt := sender.Send(context.Background(), sub, delivery)
+	if !attempt.Ok() || attempt.Body != "ok" {
+		t.Fatalf("attempt = %+v, want 2xx with body ok", attempt)
+	}
+	if got.header.Get("X-Waheim-Event") != models.EventBuildCompleted || got.header.Get("X-Waheim-Delivery") != "del-1" {
+		t.Errorf("unexpected event headers: %v", got.header)
+	}
+	if ts := got.header.Get("X-Waheim-Timestamp"); ts != "1700000000" {
+		t.Errorf("timestamp = %q", ts)
+	}
+	want := SignWebhookPayload("whsec_test", "1700000000", got.body)
+	if sig := got.header.Get("X-Waheim-Signature"); sig != want {
+		t.Errorf("signature = %q, want %q", sig, want)
+	}
+	var envelope WebhookEnvelope
+	if err := json.Unmarshal(got.body, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Id != "evt-1" || envelope.Type != models.EventBuildCompleted || string(envelope.Data) != `{"app_id":"app-1"}` {
+		t.Errorf("envelope = %+v", envelope)
+	}
+}
+
+func TestWebhookRetriesUntilMaxAttempts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	s := &WebhookService{Sender: &WebhookSender{Client: receiver.Client()}, MaxAttempts: 3}
+	sub := models.WebhookSubscription{Id: "sub-1", Url: receiver.URL, Secret: "whsec_test"}
+	delivery := models.WebhookDelivery{Id: "del-1", EventId: "evt-1", EventType: models.EventRatingPosted, Payload: []byte(`{}`)}
+
+	for i := 1; i <= 3; i++ {
+		s.applyAttempt(&delivery, s.Sender.Send(context.Background(), sub, delivery), s.MaxAttempts)
+		if delivery.ResponseCode.Int64 != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: response code = %d", i, delivery.ResponseCode.Int64)
+		}
+	}
+	if delivery.Status != models.WebhookDeliveryFailed || delivery.Attempts != 3 {
+		t.Errorf("status = %s after %d attempts, want failed after 3", delivery.Status, delivery.Attempts)
+	}
+	if webhookBackoff(1) != 30*time.Second || webhookBackoff(3) != 2*time.Minute || webhookBackoff(20) != 6*time.Hour {
+		t.Errorf("unexpected backoff schedule")
+	}
+}